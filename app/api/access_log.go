@@ -0,0 +1,40 @@
+package api
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+
+	"sample-rest-api/app/logging"
+)
+
+// AccessLogMiddleware - stores a logger carrying the request ID in the request context (see logging.FromContext),
+// then logs the method, route template, status, response size and duration of the request.
+// It must run after RequestIDMiddleware, and after the tracing middleware for the trace ID to be logged.
+func (h *Handler) AccessLogMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		logger := h.Logger
+		if logger == nil {
+			logger = slog.Default()
+		}
+		logger = logger.With("request_id", RequestID(r))
+		if spanContext := trace.SpanContextFromContext(r.Context()); spanContext.HasTraceID() {
+			logger = logger.With("trace_id", spanContext.TraceID().String())
+		}
+
+		start := time.Now()
+		recorder := NewResponseRecorder(w)
+		next.ServeHTTP(recorder, r.WithContext(logging.NewContext(r.Context(), logger)))
+
+		logger.Info("Request handled",
+			"method", r.Method,
+			"route", RouteTemplate(r),
+			"path", r.URL.Path,
+			"status", recorder.Status,
+			"bytes", recorder.Bytes,
+			"duration_ms", float64(time.Since(start).Microseconds())/1000,
+		)
+	})
+}
@@ -0,0 +1,54 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+
+	"sample-rest-api/app/logging"
+)
+
+func TestAccessLogMiddleware(t *testing.T) {
+	t.Run("Access log with request ID", func(t *testing.T) {
+		var output bytes.Buffer
+		handler := Init(nil)
+		handler.Logger = slog.New(slog.NewJSONHandler(&output, nil))
+
+		router := mux.NewRouter()
+		router.Use(RequestIDMiddleware, handler.AccessLogMiddleware)
+		router.HandleFunc("/v1/users/{id}", func(w http.ResponseWriter, r *http.Request) {
+			logging.FromContext(r.Context()).Info("Fetching user")
+			w.WriteHeader(http.StatusAccepted)
+			w.Write([]byte("hello"))
+		})
+
+		request := httptest.NewRequest("GET", "/v1/users/42", nil)
+		request.Header.Set(RequestIDHeader, "abc-123")
+		router.ServeHTTP(httptest.NewRecorder(), request)
+
+		lines := strings.Split(strings.TrimSpace(output.String()), "\n")
+		if len(lines) != 2 {
+			t.Fatal("Expected a handler line and an access line:", output.String())
+		}
+		handlerEntry := make(map[string]interface{})
+		accessEntry := make(map[string]interface{})
+		if json.Unmarshal([]byte(lines[0]), &handlerEntry) != nil || json.Unmarshal([]byte(lines[1]), &accessEntry) != nil {
+			t.Fatal("Invalid log lines:", output.String())
+		}
+
+		if handlerEntry["request_id"] != "abc-123" {
+			t.Error("Handler logs should carry the request ID:", lines[0])
+		}
+		if accessEntry["request_id"] != "abc-123" || accessEntry["method"] != "GET" ||
+			accessEntry["route"] != "/v1/users/{id}" || accessEntry["status"] != float64(http.StatusAccepted) ||
+			accessEntry["bytes"] != float64(5) || accessEntry["duration_ms"] == nil {
+			t.Error("Incorrect access log entry:", lines[1])
+		}
+	})
+}
@@ -0,0 +1,54 @@
+package api
+
+import (
+	"crypto/subtle"
+	"net/http"
+)
+
+// Error codes sent when a request is not authorized
+const (
+	CodeUnauthorized = "unauthorized"
+	CodeForbidden    = "forbidden"
+)
+
+// RequireAdmin - allows the request only if it is made with the configured admin token; admin operations
+// are disabled when no admin token is configured. With authentication enabled, AuthMiddleware has already
+// checked the token, so the authenticated principal must be the admin; otherwise the bearer token is checked.
+func (h *Handler) RequireAdmin(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if h.AdminToken == "" {
+			SendError(w, r, NewError(http.StatusForbidden, CodeForbidden, "Admin operations are disabled."))
+			return
+		}
+
+		if h.Auth != nil {
+			principal, ok := PrincipalFromContext(r.Context())
+			if !ok {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="admin"`)
+				SendError(w, r, NewError(http.StatusUnauthorized, CodeUnauthorized, "An admin bearer token is required."))
+				return
+			}
+			// Only the admin token principal has no claims, so a JWT with an admin sub claim is not the admin
+			if principal.Subject != AdminSubject || principal.Claims != nil {
+				SendError(w, r, NewError(http.StatusForbidden, CodeForbidden, "Admin privileges are required."))
+				return
+			}
+
+			next(w, r)
+			return
+		}
+
+		token := bearerToken(r)
+		if token == "" {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="admin"`)
+			SendError(w, r, NewError(http.StatusUnauthorized, CodeUnauthorized, "An admin bearer token is required."))
+			return
+		}
+		if subtle.ConstantTimeCompare([]byte(token), []byte(h.AdminToken)) != 1 {
+			SendError(w, r, NewError(http.StatusForbidden, CodeForbidden, "The admin token is invalid."))
+			return
+		}
+
+		next(w, r)
+	}
+}
@@ -2,7 +2,10 @@ package api
 
 import (
 	"encoding/json"
+	"log/slog"
 	"net/http"
+	"sync/atomic"
+	"time"
 
 	"github.com/jmoiron/sqlx"
 )
@@ -10,13 +13,55 @@ import (
 // Handler - Holds API specific dependencies
 type Handler struct {
 	DB *sqlx.DB
+	// Logger - base logger of the API; requests log through a copy carrying the request ID
+	Logger *slog.Logger
+	// CursorKey - key for signing pagination cursors
+	CursorKey []byte
+	// AdminToken - bearer token required for admin operations; disabled if empty
+	AdminToken string
+	// Auth - validates the bearer tokens of API requests; requests are not authenticated if nil
+	Auth *Authenticator
+	// ReadinessTimeout - time allowed for the readiness checks
+	ReadinessTimeout time.Duration
+
+	// pageLimits - current PageLimits, may change at runtime
+	pageLimits atomic.Value
+	// readinessChecks - dependencies checked by the readiness endpoint, by name
+	readinessChecks map[string]ReadinessCheck
+	// notReady - set to 1 once the instance is marked not ready
+	notReady int32
 }
 
-// Init - Initialize API
+// Init - Initialize API; cursors are signed with a random key, unless one is configured.
+// The database connection, if any, is checked for readiness.
 func Init(db *sqlx.DB) *Handler {
-	return &Handler{
-		DB: db,
+	handler := &Handler{
+		DB:               db,
+		Logger:           slog.Default(),
+		CursorKey:        NewCursorKey(),
+		ReadinessTimeout: DefaultReadinessTimeout,
+	}
+	handler.SetPageLimits(DefaultPageLimits)
+	if db != nil {
+		handler.AddReadinessCheck("database", handler.pingDatabase)
 	}
+
+	return handler
+}
+
+// PageLimits - returns the current page size limits of list requests
+func (h *Handler) PageLimits() PageLimits {
+	limits, ok := h.pageLimits.Load().(PageLimits)
+	if !ok {
+		return DefaultPageLimits
+	}
+
+	return limits
+}
+
+// SetPageLimits - changes the page size limits of list requests; safe for concurrent use
+func (h *Handler) SetPageLimits(limits PageLimits) {
+	h.pageLimits.Store(limits)
 }
 
 // SendJSONResponse -
@@ -25,15 +70,11 @@ func SendJSONResponse(w http.ResponseWriter, statusCode int, content interface{}
 	jsonContent, err := json.Marshal(content)
 	if err != nil {
 		// Marshalling error, send 500
-		SendError(w, http.StatusInternalServerError, err.Error())
+		writeProblem(w, slog.Default(), InternalError(err))
+		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(statusCode)
 	w.Write(jsonContent)
 }
-
-// SendError -
-func SendError(w http.ResponseWriter, statusCode int, errorMessage string) {
-	http.Error(w, errorMessage, statusCode)
-}
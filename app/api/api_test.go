@@ -32,16 +32,3 @@ func TestSendJSONResponse(t *testing.T) {
 
 	})
 }
-
-func TestSendError(t *testing.T) {
-	t.Run("Send Error", func(t *testing.T) {
-		// Init response writer
-		w := httptest.NewRecorder()
-
-		SendError(w, 500, "")
-		if w.Code != 500 {
-			t.Error("Invalid response.")
-		}
-
-	})
-}
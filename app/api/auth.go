@@ -0,0 +1,172 @@
+package api
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// AdminSubject - subject of the principal authenticated with the admin token
+const AdminSubject = "admin"
+
+// authRealm - realm sent in the WWW-Authenticate header of API requests
+const authRealm = "api"
+
+// Principal - the authenticated caller of a request
+type Principal struct {
+	// Subject - the sub claim of the token, or AdminSubject
+	Subject string
+	// Issuer - the iss claim of the token
+	Issuer string
+	// Audience - the aud claim of the token
+	Audience []string
+	// Claims - all claims of the token; nil for the admin token
+	Claims jwt.MapClaims
+}
+
+// principalKey - context key for the authenticated principal
+type principalKey struct{}
+
+// NewPrincipalContext - returns a copy of the context carrying the principal
+func NewPrincipalContext(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// PrincipalFromContext - returns the authenticated principal of the request context, if any
+func PrincipalFromContext(ctx context.Context) (*Principal, bool) {
+	principal, ok := ctx.Value(principalKey{}).(*Principal)
+
+	return principal, ok && principal != nil
+}
+
+// Authenticator - validates JWT bearer tokens: HS256 tokens with the shared secret,
+// RS256 and ES256 tokens with the keys of the key set
+type Authenticator struct {
+	// Secret - shared secret of HS256 tokens; HS256 is rejected if empty
+	Secret []byte
+	// Keys - public keys of RS256 and ES256 tokens; both are rejected if nil
+	Keys *KeySet
+	// Issuer - required iss claim; not checked if empty
+	Issuer string
+	// Audience - required aud claim; not checked if empty
+	Audience string
+	// Leeway - clock skew allowed when checking the exp and nbf claims
+	Leeway time.Duration
+}
+
+// Authenticate - validates the signature and claims of the token and returns its principal.
+// The exp claim is required.
+func (a *Authenticator) Authenticate(ctx context.Context, token string) (*Principal, error) {
+	methods := make([]string, 0, 3)
+	if len(a.Secret) > 0 {
+		methods = append(methods, jwt.SigningMethodHS256.Alg())
+	}
+	if a.Keys != nil {
+		methods = append(methods, jwt.SigningMethodRS256.Alg(), jwt.SigningMethodES256.Alg())
+	}
+	options := []jwt.ParserOption{
+		jwt.WithValidMethods(methods),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(a.Leeway),
+	}
+	if a.Issuer != "" {
+		options = append(options, jwt.WithIssuer(a.Issuer))
+	}
+	if a.Audience != "" {
+		options = append(options, jwt.WithAudience(a.Audience))
+	}
+
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(token, claims, func(token *jwt.Token) (interface{}, error) {
+		if token.Method == jwt.SigningMethodHS256 {
+			return a.Secret, nil
+		}
+		keyID, _ := token.Header["kid"].(string)
+		return a.Keys.Key(ctx, keyID)
+	}, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	principal := &Principal{Claims: claims}
+	principal.Subject, _ = claims.GetSubject()
+	principal.Issuer, _ = claims.GetIssuer()
+	principal.Audience, _ = claims.GetAudience()
+
+	return principal, nil
+}
+
+// AuthMiddleware - requires a valid JWT bearer token, or the admin token, and stores the
+// authenticated principal in the request context. Failures are answered with 401 and a
+// WWW-Authenticate challenge. Requests pass through unauthenticated if no Authenticator is set.
+func (h *Handler) AuthMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if h.Auth == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		token := bearerToken(r)
+		if token == "" {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="`+authRealm+`"`)
+			SendError(w, r, NewError(http.StatusUnauthorized, CodeUnauthorized, "A bearer token is required."))
+			return
+		}
+
+		var principal *Principal
+		if h.AdminToken != "" && subtle.ConstantTimeCompare([]byte(token), []byte(h.AdminToken)) == 1 {
+			principal = &Principal{Subject: AdminSubject}
+		} else {
+			var err error
+			principal, err = h.Auth.Authenticate(r.Context(), token)
+			if err != nil {
+				description := tokenErrorDescription(err)
+				w.Header().Set("WWW-Authenticate",
+					`Bearer realm="`+authRealm+`", error="invalid_token", error_description="`+description+`"`)
+				SendError(w, r, NewError(http.StatusUnauthorized, CodeUnauthorized, "The bearer token is invalid: "+description+"."))
+				return
+			}
+		}
+
+		next.ServeHTTP(w, r.WithContext(NewPrincipalContext(r.Context(), principal)))
+	})
+}
+
+// bearerToken - returns the token of the Authorization header, empty if it is not a bearer token
+func bearerToken(r *http.Request) string {
+	scheme, token, found := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") {
+		return ""
+	}
+
+	return strings.TrimSpace(token)
+}
+
+// tokenErrorDescription - describes why the token was rejected, without echoing token contents
+func tokenErrorDescription(err error) string {
+	switch {
+	case errors.Is(err, jwt.ErrTokenMalformed):
+		return "malformed token"
+	case errors.Is(err, jwt.ErrTokenExpired):
+		return "token is expired"
+	case errors.Is(err, jwt.ErrTokenNotValidYet):
+		return "token is not valid yet"
+	case errors.Is(err, jwt.ErrTokenRequiredClaimMissing):
+		return "token has no expiration time"
+	case errors.Is(err, jwt.ErrTokenInvalidIssuer):
+		return "invalid issuer"
+	case errors.Is(err, jwt.ErrTokenInvalidAudience):
+		return "invalid audience"
+	case errors.Is(err, ErrUnknownKey):
+		return "unknown signing key"
+	case errors.Is(err, jwt.ErrTokenSignatureInvalid), errors.Is(err, jwt.ErrTokenUnverifiable):
+		return "invalid signature"
+	default:
+		return "invalid token"
+	}
+}
@@ -0,0 +1,253 @@
+package api
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var testSecret = []byte("0123456789abcdef0123456789abcdef")
+
+// signToken - returns a token signed with the method and key
+func signToken(t *testing.T, method jwt.SigningMethod, key interface{}, keyID string, claims jwt.MapClaims) string {
+	token := jwt.NewWithClaims(method, claims)
+	if keyID != "" {
+		token.Header["kid"] = keyID
+	}
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal("Could not sign token:", err)
+	}
+
+	return signed
+}
+
+// validClaims - returns claims accepted by the test authenticators
+func validClaims() jwt.MapClaims {
+	return jwt.MapClaims{
+		"sub": "user-1",
+		"iss": "https://issuer.test",
+		"aud": "sample-rest-api",
+		"exp": time.Now().Add(time.Hour).Unix(),
+		"nbf": time.Now().Add(-time.Minute).Unix(),
+	}
+}
+
+// withClaim - returns the valid claims with one claim changed, or removed if the value is nil
+func withClaim(name string, value interface{}) jwt.MapClaims {
+	claims := validClaims()
+	if value == nil {
+		delete(claims, name)
+	} else {
+		claims[name] = value
+	}
+
+	return claims
+}
+
+func TestAuthenticateHS256(t *testing.T) {
+	authenticator := &Authenticator{Secret: testSecret, Issuer: "https://issuer.test", Audience: "sample-rest-api"}
+
+	// Multiple test cases
+	var tests = []struct {
+		name    string
+		token   string
+		problem string
+	}{
+		{"Valid token", signToken(t, jwt.SigningMethodHS256, testSecret, "", validClaims()), ""},
+		{"Expired token", signToken(t, jwt.SigningMethodHS256, testSecret, "", withClaim("exp", time.Now().Add(-time.Hour).Unix())), "token is expired"},
+		{"Token not valid yet", signToken(t, jwt.SigningMethodHS256, testSecret, "", withClaim("nbf", time.Now().Add(time.Hour).Unix())), "token is not valid yet"},
+		{"Missing expiration", signToken(t, jwt.SigningMethodHS256, testSecret, "", withClaim("exp", nil)), "token has no expiration time"},
+		{"Wrong issuer", signToken(t, jwt.SigningMethodHS256, testSecret, "", withClaim("iss", "https://other.test")), "invalid issuer"},
+		{"Wrong audience", signToken(t, jwt.SigningMethodHS256, testSecret, "", withClaim("aud", "other-api")), "invalid audience"},
+		{"Wrong secret", signToken(t, jwt.SigningMethodHS256, []byte("another-secret-another-secret-00"), "", validClaims()), "invalid signature"},
+		{"Unsigned token", signToken(t, jwt.SigningMethodNone, jwt.UnsafeAllowNoneSignatureType, "", validClaims()), "invalid signature"},
+		{"Malformed token", "not.a.token", "malformed token"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			principal, err := authenticator.Authenticate(context.Background(), test.token)
+			if test.problem == "" {
+				if err != nil || principal.Subject != "user-1" || principal.Issuer != "https://issuer.test" ||
+					len(principal.Audience) != 1 || principal.Audience[0] != "sample-rest-api" {
+					t.Error("Incorrect principal:", principal, err)
+				}
+				return
+			}
+			if err == nil || tokenErrorDescription(err) != test.problem {
+				t.Errorf("Expected %q, got %v", test.problem, err)
+			}
+		})
+	}
+
+	t.Run("Leeway", func(t *testing.T) {
+		authenticator := &Authenticator{Secret: testSecret, Leeway: time.Minute}
+		token := signToken(t, jwt.SigningMethodHS256, testSecret, "", withClaim("exp", time.Now().Add(-30*time.Second).Unix()))
+		if _, err := authenticator.Authenticate(context.Background(), token); err != nil {
+			t.Error("Token expired within the leeway should be accepted:", err)
+		}
+	})
+}
+
+func TestAuthenticateKeySet(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	filename := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(filename, jwksDocument(t, map[string]interface{}{"rsa-1": &rsaKey.PublicKey, "ec-1": &ecKey.PublicKey}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	keySet, err := LoadKeySet(context.Background(), filename)
+	if err != nil {
+		t.Fatal("Could not load key set:", err)
+	}
+	authenticator := &Authenticator{Keys: keySet}
+
+	// Multiple test cases
+	var tests = []struct {
+		name    string
+		token   string
+		problem string
+	}{
+		{"RS256 token", signToken(t, jwt.SigningMethodRS256, rsaKey, "rsa-1", validClaims()), ""},
+		{"ES256 token", signToken(t, jwt.SigningMethodES256, ecKey, "ec-1", validClaims()), ""},
+		{"Unknown key", signToken(t, jwt.SigningMethodRS256, rsaKey, "rsa-2", validClaims()), "unknown signing key"},
+		{"Key of another type", signToken(t, jwt.SigningMethodRS256, rsaKey, "ec-1", validClaims()), "invalid signature"},
+		{"HS256 without secret", signToken(t, jwt.SigningMethodHS256, testSecret, "", validClaims()), "invalid signature"},
+		{"RS384 token", signToken(t, jwt.SigningMethodRS384, rsaKey, "rsa-1", validClaims()), "invalid signature"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			principal, err := authenticator.Authenticate(context.Background(), test.token)
+			if test.problem == "" {
+				if err != nil || principal.Subject != "user-1" {
+					t.Error("Incorrect principal:", principal, err)
+				}
+				return
+			}
+			if err == nil || tokenErrorDescription(err) != test.problem {
+				t.Errorf("Expected %q, got %v", test.problem, err)
+			}
+		})
+	}
+}
+
+func TestAuthMiddleware(t *testing.T) {
+	apiHandler := Init(nil)
+	apiHandler.AdminToken = "admin-token"
+	apiHandler.Auth = &Authenticator{Secret: testSecret}
+
+	// Multiple test cases
+	var tests = []struct {
+		name          string
+		authorization string
+		code          int
+		subject       string
+		challenge     string
+	}{
+		{"Missing token", "", 401, "", `Bearer realm="api"`},
+		{"Other scheme", "Basic dXNlcjpwYXNz", 401, "", `Bearer realm="api"`},
+		{"Invalid token", "Bearer invalid", 401, "", `error="invalid_token"`},
+		{"Valid token", "Bearer " + signToken(t, jwt.SigningMethodHS256, testSecret, "", validClaims()), 200, "user-1", ""},
+		{"Lowercase scheme", "bearer " + signToken(t, jwt.SigningMethodHS256, testSecret, "", validClaims()), 200, "user-1", ""},
+		{"Admin token", "Bearer admin-token", 200, AdminSubject, ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var subject string
+			handler := apiHandler.AuthMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if principal, ok := PrincipalFromContext(r.Context()); ok {
+					subject = principal.Subject
+				}
+			}))
+
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest("DELETE", "/v1/users/1", nil)
+			if test.authorization != "" {
+				r.Header.Set("Authorization", test.authorization)
+			}
+			handler.ServeHTTP(w, r)
+
+			if w.Code != test.code || subject != test.subject {
+				t.Errorf("Expected %d for %q, got %d for %q", test.code, test.subject, w.Code, subject)
+			}
+			if !strings.Contains(w.Header().Get("WWW-Authenticate"), test.challenge) ||
+				(test.challenge == "") != (w.Header().Get("WWW-Authenticate") == "") {
+				t.Error("Incorrect challenge:", w.Header().Get("WWW-Authenticate"))
+			}
+		})
+	}
+
+	t.Run("Authentication disabled", func(t *testing.T) {
+		called := false
+		handler := Init(nil).AuthMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			called = true
+		}))
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/v1/users", nil))
+		if !called {
+			t.Error("Requests should pass through without an authenticator.")
+		}
+	})
+}
+
+func TestRequireAdminAuthenticated(t *testing.T) {
+	apiHandler := Init(nil)
+	apiHandler.AdminToken = "admin-token"
+	apiHandler.Auth = &Authenticator{Secret: testSecret}
+	handler := apiHandler.AuthMiddleware(apiHandler.RequireAdmin(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	// Multiple test cases
+	var tests = []struct {
+		name          string
+		authorization string
+		code          int
+	}{
+		{"Missing token", "", 401},
+		{"Admin token", "Bearer admin-token", 204},
+		{"User token", "Bearer " + signToken(t, jwt.SigningMethodHS256, testSecret, "", validClaims()), 403},
+		{"Token with an admin subject", "Bearer " + signToken(t, jwt.SigningMethodHS256, testSecret, "", withClaim("sub", AdminSubject)), 403},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest("POST", "/v1/users/1:purge", nil)
+			if test.authorization != "" {
+				r.Header.Set("Authorization", test.authorization)
+			}
+			handler.ServeHTTP(w, r)
+
+			if w.Code != test.code {
+				t.Errorf("Expected %d, got %d", test.code, w.Code)
+			}
+		})
+	}
+
+	t.Run("No principal in the context", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest("POST", "/v1/users/1:purge", nil)
+		r.Header.Set("Authorization", "Bearer admin-token")
+		apiHandler.RequireAdmin(func(w http.ResponseWriter, r *http.Request) {})(w, r)
+
+		if w.Code != http.StatusUnauthorized {
+			t.Error("Requests not authenticated by the middleware should be rejected, got:", w.Code)
+		}
+	})
+}
@@ -0,0 +1,67 @@
+package api
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// CodeInvalidCursor - error code sent when a pagination cursor is malformed or tampered with
+const CodeInvalidCursor = "invalid_cursor"
+
+// invalidCursorError - sent when a cursor cannot be decoded
+var invalidCursorError = NewError(http.StatusBadRequest, CodeInvalidCursor, "The pagination cursor is invalid.").
+	WithDetails(FieldError{Field: "cursor", Code: CodeInvalidCursor, Message: "Use the cursor returned by the previous page."})
+
+// NewCursorKey - generates a random key for signing cursors
+func NewCursorKey() []byte {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		panic("api: cannot generate cursor key: " + err.Error())
+	}
+
+	return key
+}
+
+// EncodeCursor - encodes the position into an opaque token, signed with the key (HMAC-SHA256)
+func EncodeCursor(key []byte, position interface{}) (string, error) {
+	payload, err := json.Marshal(position)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(payload) + "." + base64.RawURLEncoding.EncodeToString(sign(key, payload)), nil
+}
+
+// DecodeCursor - verifies the token signature and decodes the position
+func DecodeCursor(key []byte, token string, position interface{}) *Error {
+	parts := strings.Split(token, ".")
+	if len(parts) != 2 {
+		return invalidCursorError
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return invalidCursorError
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil || !hmac.Equal(signature, sign(key, payload)) {
+		return invalidCursorError
+	}
+
+	if err := json.Unmarshal(payload, position); err != nil {
+		return invalidCursorError
+	}
+
+	return nil
+}
+
+func sign(key []byte, payload []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(payload)
+
+	return mac.Sum(nil)
+}
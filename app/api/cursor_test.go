@@ -0,0 +1,52 @@
+package api
+
+import (
+	"strings"
+	"testing"
+)
+
+type testPosition struct {
+	ID int `json:"id"`
+}
+
+func TestCursor(t *testing.T) {
+	t.Run("Encode and decode cursor", func(t *testing.T) {
+		key := NewCursorKey()
+		token, err := EncodeCursor(key, &testPosition{ID: 42})
+		if err != nil {
+			t.Error("Unexpected error.")
+			return
+		}
+
+		position := &testPosition{}
+		if apiErr := DecodeCursor(key, token, position); apiErr != nil || position.ID != 42 {
+			t.Error("Cursor should be decoded.")
+		}
+	})
+}
+
+func TestCursorInvalid(t *testing.T) {
+	key := NewCursorKey()
+	token, _ := EncodeCursor(key, &testPosition{ID: 42})
+	tampered, _ := EncodeCursor(key, &testPosition{ID: 43})
+
+	// Multiple test cases
+	var tests = []struct {
+		name  string
+		key   []byte
+		token string
+	}{
+		{"Malformed token", key, "abc"},
+		{"Invalid encoding", key, "!!.!!"},
+		{"Signed with another key", NewCursorKey(), token},
+		{"Tampered payload", key, strings.Split(tampered, ".")[0] + "." + strings.Split(token, ".")[1]},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			apiErr := DecodeCursor(test.key, test.token, &testPosition{})
+			if apiErr == nil || apiErr.Code != CodeInvalidCursor {
+				t.Error("Invalid cursor error expected.")
+			}
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+// MaxBodySize - maximum accepted size of a request body, in bytes
+const MaxBodySize = 1 << 20
+
+// Error codes sent when the request body cannot be decoded
+const (
+	CodeUnknownField    = "unknown_field"
+	CodeBodyTooLarge    = "body_too_large"
+	CodeInvalidJSONType = "invalid_type"
+)
+
+// ReadBody - reads the request body, up to MaxBodySize bytes
+func ReadBody(w http.ResponseWriter, r *http.Request) ([]byte, *Error) {
+	body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, MaxBodySize))
+	if err != nil {
+		return nil, decodeError(err)
+	}
+
+	return body, nil
+}
+
+// DecodeJSON - strictly decodes the request body into v; unknown fields, trailing data
+// and bodies larger than MaxBodySize are rejected
+func DecodeJSON(w http.ResponseWriter, r *http.Request, v interface{}) *Error {
+	return decodeStrict(http.MaxBytesReader(w, r.Body, MaxBodySize), v)
+}
+
+// DecodeJSONBytes - strictly decodes the JSON document into v, the same way DecodeJSON does
+func DecodeJSONBytes(data []byte, v interface{}) *Error {
+	return decodeStrict(bytes.NewReader(data), v)
+}
+
+func decodeStrict(reader io.Reader, v interface{}) *Error {
+	decoder := json.NewDecoder(reader)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		return decodeError(err)
+	}
+
+	// Only a single JSON document is accepted
+	if err := decoder.Decode(&struct{}{}); err != io.EOF {
+		return NewError(http.StatusBadRequest, CodeInvalidJSON, "The request body must contain a single JSON document.")
+	}
+
+	return nil
+}
+
+// decodeError - maps body reading and JSON decoding errors to API errors
+func decodeError(err error) *Error {
+	var typeErr *json.UnmarshalTypeError
+	var maxBytesErr *http.MaxBytesError
+	switch {
+	case errors.As(err, &maxBytesErr):
+		return NewError(http.StatusRequestEntityTooLarge, CodeBodyTooLarge, "The request body is too large.")
+	case errors.As(err, &typeErr):
+		return NewError(http.StatusBadRequest, CodeInvalidJSON, "The request body contains a value of the wrong type.").
+			WithDetails(FieldError{Field: typeErr.Field, Code: CodeInvalidJSONType, Message: "The field must be of type " + typeErr.Type.String() + "."})
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		field := strings.Trim(strings.TrimPrefix(err.Error(), "json: unknown field "), `"`)
+		return NewError(http.StatusBadRequest, CodeUnknownField, "The request body contains an unknown field.").
+			WithDetails(FieldError{Field: field, Code: CodeUnknownField, Message: "The field is not supported."})
+	default:
+		return NewError(http.StatusBadRequest, CodeInvalidJSON, "The request body is not a valid JSON document.")
+	}
+}
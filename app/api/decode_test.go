@@ -0,0 +1,46 @@
+package api
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestDecodeJSON(t *testing.T) {
+	// Multiple test cases
+	var tests = []struct {
+		name   string
+		body   string
+		status int
+		code   string
+	}{
+		{"Valid body", `{"name":"abc","count":1}`, 0, ""},
+		{"Invalid JSON", `{"name":`, 400, CodeInvalidJSON},
+		{"Wrong type", `{"name":1}`, 400, CodeInvalidJSON},
+		{"Unknown field", `{"name":"abc","nam":"abc"}`, 400, CodeUnknownField},
+		{"Trailing data", `{"name":"abc"}{}`, 400, CodeInvalidJSON},
+		{"Body too large", `{"name":"` + strings.Repeat("a", MaxBodySize) + `"}`, 413, CodeBodyTooLarge},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest("POST", "/", strings.NewReader(test.body))
+
+			resource := &struct {
+				Name  string `json:"name"`
+				Count int    `json:"count"`
+			}{}
+			apiErr := DecodeJSON(w, r, resource)
+			if test.status == 0 {
+				if apiErr != nil {
+					t.Error("Unexpected decoding error.")
+				}
+				return
+			}
+
+			if apiErr == nil || apiErr.Status != test.status || apiErr.Code != test.code {
+				t.Errorf("Expected %d %s, got %v", test.status, test.code, apiErr)
+			}
+		})
+	}
+}
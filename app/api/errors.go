@@ -0,0 +1,143 @@
+package api
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"sample-rest-api/app/logging"
+)
+
+// ProblemContentType - media type of a problem details object (RFC 7807)
+const ProblemContentType = "application/problem+json"
+
+// Stable, machine-readable error codes
+const (
+	CodeBadRequest           = "bad_request"
+	CodeInvalidJSON          = "invalid_json"
+	CodeNotFound             = "not_found"
+	CodeMethodNotAllowed     = "method_not_allowed"
+	CodeUnsupportedMediaType = "unsupported_media_type"
+	CodeConflict             = "conflict"
+	CodeInternal             = "internal_error"
+	CodeTimeout              = "timeout"
+	CodeClientClosed         = "client_closed_request"
+)
+
+// StatusClientClosedRequest - non standard status (from nginx) recorded when the client
+// disconnects before the response is sent; the client never receives it
+const StatusClientClosedRequest = 499
+
+// FieldError - error related to a single field of the request
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Error - API error, rendered as a problem details object (RFC 7807)
+type Error struct {
+	Type      string       `json:"type"`
+	Title     string       `json:"title"`
+	Status    int          `json:"status"`
+	Detail    string       `json:"detail,omitempty"`
+	Code      string       `json:"code"`
+	Errors    []FieldError `json:"errors,omitempty"`
+	RequestID string       `json:"requestId,omitempty"`
+
+	// cause - underlying error, logged but never sent to the client
+	cause error
+}
+
+// NewError - creates an API error with the given status, code and human readable message
+func NewError(statusCode int, code string, message string) *Error {
+	return &Error{
+		Type:   "about:blank",
+		Title:  http.StatusText(statusCode),
+		Status: statusCode,
+		Detail: message,
+		Code:   code,
+	}
+}
+
+// InternalError - creates a sanitized API error for an unexpected error
+func InternalError(err error) *Error {
+	apiErr := NewError(http.StatusInternalServerError, CodeInternal, "An unexpected error occurred.")
+	apiErr.cause = err
+
+	return apiErr
+}
+
+// ClientClosedError - creates the API error of requests abandoned by the client; it is not logged as a failure
+func ClientClosedError() *Error {
+	apiErr := NewError(StatusClientClosedRequest, CodeClientClosed, "The client closed the request.")
+	apiErr.Title = "Client Closed Request"
+
+	return apiErr
+}
+
+// WithDetails - adds field level details to the error
+func (e *Error) WithDetails(details ...FieldError) *Error {
+	e.Errors = append(e.Errors, details...)
+	return e
+}
+
+// Error - implements the error interface
+func (e *Error) Error() string {
+	if e.cause != nil {
+		return e.Code + ": " + e.cause.Error()
+	}
+
+	return e.Code + ": " + e.Detail
+}
+
+// Unwrap - returns the underlying error, if any
+func (e *Error) Unwrap() error {
+	return e.cause
+}
+
+// SendError - sends the API error as a problem details object
+func SendError(w http.ResponseWriter, r *http.Request, apiErr *Error) {
+	// Copy the error, so shared instances are not modified
+	problem := *apiErr
+	logger := slog.Default()
+	if r != nil {
+		problem.RequestID = RequestID(r)
+		logger = logging.FromContext(r.Context())
+	}
+
+	writeProblem(w, logger, &problem)
+}
+
+// writeProblem - writes the problem details object to the response
+func writeProblem(w http.ResponseWriter, logger *slog.Logger, problem *Error) {
+	// Log the underlying error, it is not part of the response
+	if problem.cause != nil {
+		logger.Error("Request failed", "status", problem.Status, "code", problem.Code, "error", problem.cause)
+	}
+
+	jsonContent, err := json.Marshal(problem)
+	if err != nil {
+		// Should never happen, the problem only holds plain values
+		http.Error(w, http.StatusText(problem.Status), problem.Status)
+		return
+	}
+
+	w.Header().Set("Content-Type", ProblemContentType)
+	w.WriteHeader(problem.Status)
+	w.Write(jsonContent)
+}
+
+// NotFoundHandler - sends a 404 problem for unmatched routes
+func NotFoundHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		SendError(w, r, NewError(http.StatusNotFound, CodeNotFound, "The requested resource does not exist."))
+	})
+}
+
+// MethodNotAllowedHandler - sends a 405 problem for unsupported methods on matched routes
+func MethodNotAllowedHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		SendError(w, r, NewError(http.StatusMethodNotAllowed, CodeMethodNotAllowed, "The method is not allowed for the requested resource."))
+	})
+}
@@ -0,0 +1,74 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestSendError(t *testing.T) {
+	t.Run("Send Error", func(t *testing.T) {
+		// Init response writer and request
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Header.Set(RequestIDHeader, "test-request-id")
+
+		apiErr := NewError(http.StatusBadRequest, CodeBadRequest, "Bad request.").
+			WithDetails(FieldError{Field: "email", Code: "required", Message: "Email is required."})
+		SendError(w, r, apiErr)
+		if w.Code != 400 || w.Header().Get("Content-Type") != ProblemContentType {
+			t.Error("Invalid response.")
+			return
+		}
+
+		// Check response body
+		problem := &Error{}
+		err := json.Unmarshal(w.Body.Bytes(), problem)
+		if err != nil {
+			t.Error("Invalid JSON in response body.")
+			return
+		}
+		if problem.Status != 400 || problem.Code != CodeBadRequest || problem.Title != "Bad Request" ||
+			problem.RequestID != "test-request-id" || len(problem.Errors) != 1 {
+			t.Error("Incorrect response body.")
+		}
+
+		// The shared error instance must not be modified
+		if apiErr.RequestID != "" {
+			t.Error("API error should not be modified.")
+		}
+	})
+}
+
+func TestSendInternalError(t *testing.T) {
+	t.Run("Send Error - internal error is sanitized", func(t *testing.T) {
+		// Init response writer and request
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest("GET", "/", nil)
+
+		SendError(w, r, InternalError(errors.New("Error 1045: Access denied for user 'user'@'localhost'")))
+		if w.Code != 500 {
+			t.Error("Invalid response.")
+			return
+		}
+
+		if strings.Contains(w.Body.String(), "Access denied") {
+			t.Error("Internal error details should not be sent to the client.")
+		}
+	})
+}
+
+func TestNotFoundHandler(t *testing.T) {
+	t.Run("Not found handler", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest("GET", "/missing", nil)
+
+		NotFoundHandler().ServeHTTP(w, r)
+		if w.Code != 404 || w.Header().Get("Content-Type") != ProblemContentType {
+			t.Error("Invalid response.")
+		}
+	})
+}
@@ -0,0 +1,133 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"sample-rest-api/app/logging"
+)
+
+// DefaultReadinessTimeout - time allowed for all readiness checks, unless configured otherwise
+const DefaultReadinessTimeout = 2 * time.Second
+
+// Health statuses
+const (
+	StatusOK           = "ok"
+	StatusReady        = "ready"
+	StatusNotReady     = "not_ready"
+	StatusShuttingDown = "shutting_down"
+	StatusUp           = "up"
+	StatusDown         = "down"
+)
+
+// ReadinessCheck - checks that a dependency can serve requests
+type ReadinessCheck func(ctx context.Context) error
+
+// Health - body of the health and readiness responses
+type Health struct {
+	Status string                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks,omitempty"`
+}
+
+// CheckResult - outcome of a readiness check
+type CheckResult struct {
+	Status string `json:"status"`
+	// Error - reason of the failure; details are logged, not exposed
+	Error string `json:"error,omitempty"`
+}
+
+// AddReadinessCheck - registers a dependency checked by the readiness endpoint; call before serving requests
+func (h *Handler) AddReadinessCheck(name string, check ReadinessCheck) {
+	if h.readinessChecks == nil {
+		h.readinessChecks = make(map[string]ReadinessCheck)
+	}
+	h.readinessChecks[name] = check
+}
+
+// SetReady - marks the instance ready or not ready, e.g. not ready during graceful shutdown
+func (h *Handler) SetReady(ready bool) {
+	var notReady int32
+	if !ready {
+		notReady = 1
+	}
+	atomic.StoreInt32(&h.notReady, notReady)
+}
+
+// Ready - tells whether the instance is marked ready
+func (h *Handler) Ready() bool {
+	return atomic.LoadInt32(&h.notReady) == 0
+}
+
+// pingDatabase - readiness check of the database connection
+func (h *Handler) pingDatabase(ctx context.Context) error {
+	if h.DB == nil {
+		return errors.New("no database connection")
+	}
+
+	return h.DB.PingContext(ctx)
+}
+
+// Liveness - liveness probe; the process is alive as long as it serves requests
+func (h *Handler) Liveness(w http.ResponseWriter, r *http.Request) {
+	SendJSONResponse(w, http.StatusOK, &Health{Status: StatusOK})
+}
+
+// Readiness - readiness probe; runs the readiness checks concurrently within the readiness timeout,
+// responding 503 Service Unavailable if any of them fails or the instance is shutting down
+func (h *Handler) Readiness(w http.ResponseWriter, r *http.Request) {
+	if !h.Ready() {
+		SendJSONResponse(w, http.StatusServiceUnavailable, &Health{Status: StatusShuttingDown})
+		return
+	}
+
+	timeout := h.ReadinessTimeout
+	if timeout <= 0 {
+		timeout = DefaultReadinessTimeout
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), timeout)
+	defer cancel()
+
+	// Run the checks concurrently, so a slow dependency does not delay the others
+	names := make([]string, 0, len(h.readinessChecks))
+	for name := range h.readinessChecks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	errs := make([]chan error, len(names))
+	for i, name := range names {
+		errs[i] = make(chan error, 1)
+		go func(check ReadinessCheck, result chan<- error) {
+			result <- check(ctx)
+		}(h.readinessChecks[name], errs[i])
+	}
+
+	health := &Health{Status: StatusReady, Checks: make(map[string]CheckResult, len(names))}
+	status := http.StatusOK
+	for i, name := range names {
+		var err error
+		select {
+		case err = <-errs[i]:
+		case <-ctx.Done():
+			err = ctx.Err()
+		}
+		if err == nil {
+			health.Checks[name] = CheckResult{Status: StatusUp}
+			continue
+		}
+
+		logging.FromContext(r.Context()).Warn("Readiness check failed", "check", name, "error", err)
+		result := CheckResult{Status: StatusDown, Error: "unavailable"}
+		if errors.Is(err, context.DeadlineExceeded) {
+			result.Error = "timeout"
+		}
+		health.Checks[name] = result
+		health.Status = StatusNotReady
+		status = http.StatusServiceUnavailable
+	}
+
+	SendJSONResponse(w, status, health)
+}
@@ -0,0 +1,94 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+)
+
+// readiness - sends a readiness request, returning the status code and the decoded body
+func readiness(t *testing.T, handler *Handler) (int, *Health) {
+	w := httptest.NewRecorder()
+	handler.Readiness(w, httptest.NewRequest("GET", "/readyz", nil))
+
+	health := &Health{}
+	if err := json.Unmarshal(w.Body.Bytes(), health); err != nil {
+		t.Fatal("Invalid readiness response:", w.Body.String())
+	}
+
+	return w.Code, health
+}
+
+func TestLiveness(t *testing.T) {
+	t.Run("Liveness", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		Init(nil).Liveness(w, httptest.NewRequest("GET", "/healthz", nil))
+		if w.Code != http.StatusOK || w.Body.String() != `{"status":"ok"}` {
+			t.Error("Incorrect liveness response:", w.Code, w.Body.String())
+		}
+	})
+}
+
+func TestReadiness(t *testing.T) {
+	t.Run("Readiness - database up", func(t *testing.T) {
+		mockDB, mock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+		if err != nil {
+			t.Fatal("Could not create database mock.")
+		}
+		defer mockDB.Close()
+		mock.ExpectPing()
+
+		status, health := readiness(t, Init(sqlx.NewDb(mockDB, "sqlmock")))
+		if status != http.StatusOK || health.Status != StatusReady || health.Checks["database"].Status != StatusUp {
+			t.Error("Incorrect readiness response:", status, health)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("Readiness - database down", func(t *testing.T) {
+		mockDB, mock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+		if err != nil {
+			t.Fatal("Could not create database mock.")
+		}
+		defer mockDB.Close()
+		mock.ExpectPing().WillReturnError(errors.New("connection refused"))
+
+		status, health := readiness(t, Init(sqlx.NewDb(mockDB, "sqlmock")))
+		if status != http.StatusServiceUnavailable || health.Status != StatusNotReady ||
+			health.Checks["database"] != (CheckResult{Status: StatusDown, Error: "unavailable"}) {
+			t.Error("Incorrect readiness response:", status, health)
+		}
+	})
+
+	t.Run("Readiness - timeout", func(t *testing.T) {
+		handler := &Handler{ReadinessTimeout: 10 * time.Millisecond}
+		handler.AddReadinessCheck("cache", func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		})
+
+		status, health := readiness(t, handler)
+		if status != http.StatusServiceUnavailable || health.Checks["cache"].Error != "timeout" {
+			t.Error("Incorrect readiness response:", status, health)
+		}
+	})
+
+	t.Run("Readiness - shutting down", func(t *testing.T) {
+		handler := Init(nil)
+		handler.SetReady(false)
+
+		status, health := readiness(t, handler)
+		if status != http.StatusServiceUnavailable || health.Status != StatusShuttingDown {
+			t.Error("Incorrect readiness response:", status, health)
+		}
+	})
+}
@@ -0,0 +1,279 @@
+package api
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultKeySetRefreshInterval - minimum time between two fetches of a key set URL
+const DefaultKeySetRefreshInterval = time.Minute
+
+// maxKeySetSize - larger key set documents are rejected
+const maxKeySetSize = 1 << 20
+
+// keySetFetchTimeout - time allowed for refreshing a key set URL, independent of the requests waiting for it
+const keySetFetchTimeout = 10 * time.Second
+
+// ErrUnknownKey - the key set has no key with the requested ID
+var ErrUnknownKey = errors.New("unknown key")
+
+// jsonWebKey - JSON Web Key (RFC 7517); only the members of RSA and P-256 EC public keys are read
+type jsonWebKey struct {
+	KeyType string `json:"kty"`
+	KeyID   string `json:"kid"`
+	Use     string `json:"use"`
+	N       string `json:"n"`
+	E       string `json:"e"`
+	Curve   string `json:"crv"`
+	X       string `json:"x"`
+	Y       string `json:"y"`
+}
+
+// KeySet - public keys verifying RS256 and ES256 tokens, loaded from a JWKS file or URL.
+// Keys of URL sets are fetched again when a token names an unknown key, so rotated keys are picked up.
+type KeySet struct {
+	// Source - path or http(s) URL of the JWKS document
+	Source string
+	// RefreshInterval - minimum time between two fetches of a URL
+	RefreshInterval time.Duration
+	// Client - HTTP client fetching URLs
+	Client *http.Client
+
+	mu   sync.RWMutex
+	keys map[string]crypto.PublicKey
+	// lastFetch - start of the latest fetch, successful or not
+	lastFetch time.Time
+	// refreshing - closed when the running refresh completes; nil if none is running
+	refreshing chan struct{}
+}
+
+// LoadKeySet - loads the keys of the JWKS file or URL
+func LoadKeySet(ctx context.Context, source string) (*KeySet, error) {
+	keySet := &KeySet{
+		Source:          source,
+		RefreshInterval: DefaultKeySetRefreshInterval,
+		Client:          &http.Client{Timeout: keySetFetchTimeout},
+	}
+	if err := keySet.load(ctx); err != nil {
+		return nil, err
+	}
+
+	return keySet, nil
+}
+
+// Key - returns the key with the given ID; an empty ID selects the only key of the set.
+// Unknown keys of URL sets are looked up again, at most once per refresh interval: concurrent
+// requests share a single fetch, which is not cancelled when they are.
+func (ks *KeySet) Key(ctx context.Context, keyID string) (crypto.PublicKey, error) {
+	key, err := ks.lookup(keyID)
+	if err != ErrUnknownKey || !ks.isURL() {
+		return key, err
+	}
+
+	ks.mu.Lock()
+	refreshing := ks.refreshing
+	if refreshing == nil {
+		if time.Since(ks.lastFetch) < ks.RefreshInterval {
+			ks.mu.Unlock()
+			return nil, err
+		}
+		refreshing = make(chan struct{})
+		ks.refreshing = refreshing
+		ks.lastFetch = time.Now()
+		go ks.refresh(refreshing)
+	}
+	ks.mu.Unlock()
+
+	select {
+	case <-refreshing:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	return ks.lookup(keyID)
+}
+
+// refresh - fetches the key set again, then signals the waiting requests by closing done
+func (ks *KeySet) refresh(done chan struct{}) {
+	ctx, cancel := context.WithTimeout(context.Background(), keySetFetchTimeout)
+	defer cancel()
+
+	keys, err := ks.read(ctx)
+	if err != nil {
+		slog.Warn("Cannot refresh JWKS, keeping the loaded keys", "source", ks.Source, "error", err)
+	}
+
+	ks.mu.Lock()
+	if err == nil {
+		ks.keys = keys
+	}
+	ks.refreshing = nil
+	ks.mu.Unlock()
+	close(done)
+}
+
+// lookup - returns the key with the given ID from the loaded keys
+func (ks *KeySet) lookup(keyID string) (crypto.PublicKey, error) {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	if keyID == "" && len(ks.keys) == 1 {
+		for _, key := range ks.keys {
+			return key, nil
+		}
+	}
+	key, ok := ks.keys[keyID]
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+
+	return key, nil
+}
+
+// isURL - tells whether the key set is fetched over HTTP
+func (ks *KeySet) isURL() bool {
+	return strings.HasPrefix(ks.Source, "http://") || strings.HasPrefix(ks.Source, "https://")
+}
+
+// load - reads and parses the JWKS document, replacing the loaded keys
+func (ks *KeySet) load(ctx context.Context) error {
+	start := time.Now()
+	keys, err := ks.read(ctx)
+	if err != nil {
+		return err
+	}
+
+	ks.mu.Lock()
+	ks.keys = keys
+	ks.lastFetch = start
+	ks.mu.Unlock()
+
+	return nil
+}
+
+// read - reads and parses the JWKS document from the file or URL
+func (ks *KeySet) read(ctx context.Context) (map[string]crypto.PublicKey, error) {
+	var document []byte
+	var err error
+	if ks.isURL() {
+		document, err = ks.fetch(ctx)
+	} else {
+		document, err = ioutil.ReadFile(ks.Source)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot load JWKS: %w", err)
+	}
+
+	return ParseKeySet(document)
+}
+
+// fetch - downloads the JWKS document
+func (ks *KeySet) fetch(ctx context.Context) ([]byte, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, ks.Source, nil)
+	if err != nil {
+		return nil, err
+	}
+	response, err := ks.Client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d", response.StatusCode)
+	}
+
+	return ioutil.ReadAll(io.LimitReader(response.Body, maxKeySetSize))
+}
+
+// ParseKeySet - parses a JWKS document into public keys by key ID.
+// Keys which are not signature keys, or of unsupported types, are skipped.
+func ParseKeySet(document []byte) (map[string]crypto.PublicKey, error) {
+	var keySet struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := json.Unmarshal(document, &keySet); err != nil {
+		return nil, fmt.Errorf("invalid JWKS: %w", err)
+	}
+
+	keys := make(map[string]crypto.PublicKey)
+	for _, jwk := range keySet.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := jwk.publicKey()
+		if err != nil {
+			return nil, fmt.Errorf("invalid JWKS key %q: %w", jwk.KeyID, err)
+		}
+		if key != nil {
+			keys[jwk.KeyID] = key
+		}
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("invalid JWKS: no RSA or P-256 signature keys")
+	}
+
+	return keys, nil
+}
+
+// publicKey - decodes the RSA or P-256 EC public key; nil for other key types
+func (jwk *jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	switch jwk.KeyType {
+	case "RSA":
+		n, err := decodeBigInt(jwk.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(jwk.E)
+		if err != nil {
+			return nil, err
+		}
+		if !e.IsInt64() || e.Int64() < 3 {
+			return nil, errors.New("invalid RSA exponent")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		if jwk.Curve != "P-256" {
+			return nil, nil
+		}
+		x, err := decodeBigInt(jwk.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(jwk.Y)
+		if err != nil {
+			return nil, err
+		}
+		key := &ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y}
+		if !key.Curve.IsOnCurve(x, y) {
+			return nil, errors.New("point not on curve")
+		}
+		return key, nil
+	default:
+		return nil, nil
+	}
+}
+
+// decodeBigInt - decodes a base64url encoded big-endian integer
+func decodeBigInt(value string) (*big.Int, error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil || len(decoded) == 0 {
+		return nil, errors.New("invalid base64url integer")
+	}
+
+	return new(big.Int).SetBytes(decoded), nil
+}
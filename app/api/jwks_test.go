@@ -0,0 +1,174 @@
+package api
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// jwksDocument - returns a JWKS document with the RSA and EC public keys, by key ID
+func jwksDocument(t *testing.T, keys map[string]interface{}) []byte {
+	encode := func(value *big.Int) string {
+		return base64.RawURLEncoding.EncodeToString(value.Bytes())
+	}
+	jwks := make([]jsonWebKey, 0, len(keys))
+	for keyID, key := range keys {
+		switch key := key.(type) {
+		case *rsa.PublicKey:
+			jwks = append(jwks, jsonWebKey{KeyType: "RSA", KeyID: keyID, Use: "sig", N: encode(key.N), E: encode(big.NewInt(int64(key.E)))})
+		case *ecdsa.PublicKey:
+			jwks = append(jwks, jsonWebKey{KeyType: "EC", KeyID: keyID, Curve: "P-256", X: encode(key.X), Y: encode(key.Y)})
+		}
+	}
+	document, err := json.Marshal(map[string]interface{}{"keys": jwks})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return document
+}
+
+func TestParseKeySet(t *testing.T) {
+	// Multiple test cases
+	var tests = []struct {
+		name     string
+		document string
+		keys     int
+		isError  bool
+	}{
+		{"RSA key", `{"keys": [{"kty": "RSA", "kid": "1", "n": "sXch", "e": "AQAB"}]}`, 1, false},
+		{"Encryption and unsupported keys skipped", `{"keys": [{"kty": "RSA", "kid": "1", "n": "sXch", "e": "AQAB"},
+			{"kty": "RSA", "kid": "2", "use": "enc", "n": "sXch", "e": "AQAB"}, {"kty": "oct", "kid": "3", "k": "c2VjcmV0"}]}`, 1, false},
+		{"No keys", `{"keys": []}`, 0, true},
+		{"Invalid JSON", `{"keys": `, 0, true},
+		{"Invalid exponent", `{"keys": [{"kty": "RSA", "kid": "1", "n": "sXch", "e": "AQ"}]}`, 0, true},
+		{"EC point not on curve", `{"keys": [{"kty": "EC", "kid": "1", "crv": "P-256", "x": "AQ", "y": "AQ"}]}`, 0, true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			keys, err := ParseKeySet([]byte(test.document))
+			if (err != nil) != test.isError || len(keys) != test.keys {
+				t.Error("Incorrect key set:", keys, err)
+			}
+		})
+	}
+}
+
+func TestKeySetURL(t *testing.T) {
+	t.Run("Key set refreshed on unknown key", func(t *testing.T) {
+		oldKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		newKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		var rotated, fetches int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&fetches, 1)
+			keys := map[string]interface{}{"old": &oldKey.PublicKey}
+			if atomic.LoadInt32(&rotated) == 1 {
+				keys["new"] = &newKey.PublicKey
+			}
+			w.Write(jwksDocument(t, keys))
+		}))
+		defer server.Close()
+
+		keySet, err := LoadKeySet(context.Background(), server.URL)
+		if err != nil {
+			t.Fatal("Could not load key set:", err)
+		}
+		keySet.RefreshInterval = 0
+		authenticator := &Authenticator{Keys: keySet}
+
+		if _, err := authenticator.Authenticate(context.Background(), signToken(t, jwt.SigningMethodES256, oldKey, "", validClaims())); err != nil {
+			t.Error("Token without key ID should use the only key:", err)
+		}
+
+		atomic.StoreInt32(&rotated, 1)
+		if _, err := authenticator.Authenticate(context.Background(), signToken(t, jwt.SigningMethodES256, newKey, "new", validClaims())); err != nil {
+			t.Error("Rotated key should be fetched:", err)
+		}
+		if atomic.LoadInt32(&fetches) != 2 {
+			t.Error("Incorrect number of fetches:", fetches)
+		}
+
+		// Unknown keys are not fetched again within the refresh interval
+		keySet.RefreshInterval = time.Hour
+		if _, err := keySet.Key(context.Background(), "unknown"); err != ErrUnknownKey {
+			t.Error("Expected an unknown key, got:", err)
+		}
+		if atomic.LoadInt32(&fetches) != 2 {
+			t.Error("Key set should not be fetched within the refresh interval:", fetches)
+		}
+	})
+
+	t.Run("Concurrent refreshes share one fetch", func(t *testing.T) {
+		oldKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		newKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		var fetches int32
+		release := make(chan struct{})
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if atomic.AddInt32(&fetches, 1) == 1 {
+				w.Write(jwksDocument(t, map[string]interface{}{"old": &oldKey.PublicKey}))
+				return
+			}
+			<-release
+			w.Write(jwksDocument(t, map[string]interface{}{"old": &oldKey.PublicKey, "new": &newKey.PublicKey}))
+		}))
+		defer server.Close()
+
+		keySet, err := LoadKeySet(context.Background(), server.URL)
+		if err != nil {
+			t.Fatal("Could not load key set:", err)
+		}
+		keySet.RefreshInterval = 0
+
+		// A request giving up does not abort the fetch the others wait for
+		cancelled, cancel := context.WithCancel(context.Background())
+		cancel()
+		if _, err := keySet.Key(cancelled, "new"); err != context.Canceled {
+			t.Error("Cancelled request should stop waiting, got:", err)
+		}
+		var wg sync.WaitGroup
+		errs := make(chan error, 10)
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, err := keySet.Key(context.Background(), "new")
+				errs <- err
+			}()
+		}
+		time.Sleep(50 * time.Millisecond)
+		close(release)
+		wg.Wait()
+		close(errs)
+
+		for err := range errs {
+			if err != nil {
+				t.Error("Rotated key should be fetched:", err)
+			}
+		}
+		if atomic.LoadInt32(&fetches) != 2 {
+			t.Error("Concurrent requests should share one fetch:", fetches)
+		}
+	})
+
+	t.Run("Key set URL unavailable", func(t *testing.T) {
+		server := httptest.NewServer(http.NotFoundHandler())
+		defer server.Close()
+
+		if _, err := LoadKeySet(context.Background(), server.URL); err == nil {
+			t.Error("Loading should fail on error responses.")
+		}
+	})
+}
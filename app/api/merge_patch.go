@@ -0,0 +1,46 @@
+package api
+
+import (
+	"encoding/json"
+)
+
+// MergePatchContentType - media type of a JSON Merge Patch document (RFC 7396)
+const MergePatchContentType = "application/merge-patch+json"
+
+// MergePatch - applies a JSON Merge Patch (RFC 7396) document to the given JSON document
+func MergePatch(document []byte, patch []byte) ([]byte, error) {
+	var target interface{}
+	if err := json.Unmarshal(document, &target); err != nil {
+		return nil, err
+	}
+
+	var patchValue interface{}
+	if err := json.Unmarshal(patch, &patchValue); err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(mergeValue(target, patchValue))
+}
+
+// mergeValue - recursive MergePatch algorithm as described in RFC 7396, section 2
+func mergeValue(target interface{}, patch interface{}) interface{} {
+	patchObject, ok := patch.(map[string]interface{})
+	if !ok {
+		// Non-object patches replace the target entirely
+		return patch
+	}
+
+	targetObject, ok := target.(map[string]interface{})
+	if !ok {
+		targetObject = make(map[string]interface{})
+	}
+	for name, value := range patchObject {
+		if value == nil {
+			delete(targetObject, name)
+			continue
+		}
+		targetObject[name] = mergeValue(targetObject[name], value)
+	}
+
+	return targetObject
+}
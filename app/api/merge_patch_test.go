@@ -0,0 +1,50 @@
+package api
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestMergePatch(t *testing.T) {
+	// Multiple test cases, taken from RFC 7396, appendix A
+	var tests = []struct {
+		name     string
+		document string
+		patch    string
+		expected string
+	}{
+		{"Replace member", `{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{"Add member", `{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{"Remove member", `{"a":"b"}`, `{"a":null}`, `{}`},
+		{"Nested objects", `{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{"Replace array", `{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{"Non-object patch", `{"a":"foo"}`, `"bar"`, `"bar"`},
+		{"Non-object target", `["a"]`, `{"a":"b"}`, `{"a":"b"}`},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result, err := MergePatch([]byte(test.document), []byte(test.patch))
+			if err != nil {
+				t.Error("Unexpected error.")
+				return
+			}
+
+			var actual, expected interface{}
+			json.Unmarshal(result, &actual)
+			json.Unmarshal([]byte(test.expected), &expected)
+			if !reflect.DeepEqual(actual, expected) {
+				t.Errorf("Expected %s, got %s", test.expected, result)
+			}
+		})
+	}
+}
+
+func TestMergePatchInvalid(t *testing.T) {
+	t.Run("Merge patch - invalid patch", func(t *testing.T) {
+		_, err := MergePatch([]byte(`{}`), []byte(`{`))
+		if err == nil {
+			t.Error("Error expected on invalid patch.")
+		}
+	})
+}
@@ -0,0 +1,112 @@
+package api
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// PageLimits - page size limits of list requests
+type PageLimits struct {
+	// Default - limit used when the limit parameter is missing
+	Default int
+	// Max - largest accepted limit parameter
+	Max int
+}
+
+// DefaultPageLimits - page size limits used unless configured otherwise
+var DefaultPageLimits = PageLimits{Default: 10, Max: 25}
+
+// Pagination - offset pagination parameters of a list request
+type Pagination struct {
+	Limit  int
+	Offset int
+}
+
+// Page - paginated list response
+type Page struct {
+	Items      interface{} `json:"items"`
+	Total      int64       `json:"total"`
+	Limit      int         `json:"limit"`
+	Offset     *int        `json:"offset,omitempty"`
+	NextCursor string      `json:"nextCursor,omitempty"`
+}
+
+// ParsePagination - reads the limit and offset query parameters; out of range values are rejected
+func ParsePagination(query url.Values, limits PageLimits) (*Pagination, *Error) {
+	pagination := &Pagination{Limit: limits.Default}
+	fieldErrors := make([]FieldError, 0)
+
+	if value := query.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > limits.Max {
+			fieldErrors = append(fieldErrors, FieldError{Field: "limit", Code: "out_of_range",
+				Message: "The limit must be an integer between 1 and " + strconv.Itoa(limits.Max) + "."})
+		}
+		pagination.Limit = limit
+	}
+	if value := query.Get("offset"); value != "" {
+		offset, err := strconv.Atoi(value)
+		if err != nil || offset < 0 {
+			fieldErrors = append(fieldErrors, FieldError{Field: "offset", Code: "out_of_range",
+				Message: "The offset must be a non-negative integer."})
+		}
+		pagination.Offset = offset
+	}
+
+	if len(fieldErrors) > 0 {
+		return nil, NewError(http.StatusBadRequest, CodeInvalidParameter, "The pagination parameters are invalid.").
+			WithDetails(fieldErrors...)
+	}
+
+	return pagination, nil
+}
+
+// SendPage - sends the page as a JSON response, with RFC 8288 Link headers to the related pages
+func SendPage(w http.ResponseWriter, r *http.Request, statusCode int, page *Page) {
+	links := make([]string, 0, 4)
+	addLink := func(rel string, parameters map[string]string) {
+		links = append(links, `<`+pageURL(r.URL, parameters)+`>; rel="`+rel+`"`)
+	}
+
+	if page.Offset != nil {
+		// Offset pagination: every page can be addressed
+		offset := *page.Offset
+		addLink("first", map[string]string{"offset": "0"})
+		if offset > 0 {
+			prevOffset := offset - page.Limit
+			if prevOffset < 0 {
+				prevOffset = 0
+			}
+			addLink("prev", map[string]string{"offset": strconv.Itoa(prevOffset)})
+		}
+		if int64(offset+page.Limit) < page.Total {
+			addLink("next", map[string]string{"offset": strconv.Itoa(offset + page.Limit)})
+		}
+		lastOffset := 0
+		if page.Total > 0 {
+			lastOffset = int((page.Total - 1) / int64(page.Limit) * int64(page.Limit))
+		}
+		addLink("last", map[string]string{"offset": strconv.Itoa(lastOffset)})
+	} else {
+		// Cursor pagination: only the first and next pages can be addressed
+		addLink("first", map[string]string{"cursor": ""})
+		if page.NextCursor != "" {
+			addLink("next", map[string]string{"cursor": page.NextCursor})
+		}
+	}
+	w.Header().Set("Link", strings.Join(links, ", "))
+
+	SendJSONResponse(w, statusCode, page)
+}
+
+// pageURL - returns the request URL (path and query), with the given query parameters replaced
+func pageURL(requestURL *url.URL, parameters map[string]string) string {
+	query := requestURL.Query()
+	for name, value := range parameters {
+		query.Set(name, value)
+	}
+
+	return requestURL.Path + "?" + query.Encode()
+}
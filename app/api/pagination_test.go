@@ -0,0 +1,83 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestParsePagination(t *testing.T) {
+	// Multiple test cases
+	var tests = []struct {
+		name    string
+		query   string
+		limit   int
+		offset  int
+		isError bool
+	}{
+		{"Defaults", "", DefaultPageLimits.Default, 0, false},
+		{"Valid values", "limit=25&offset=50", 25, 50, false},
+		{"Limit too large", "limit=26", 0, 0, true},
+		{"Limit too small", "limit=0", 0, 0, true},
+		{"Limit not a number", "limit=ten", 0, 0, true},
+		{"Negative offset", "offset=-1", 0, 0, true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			query, _ := url.ParseQuery(test.query)
+			pagination, apiErr := ParsePagination(query, DefaultPageLimits)
+			if (apiErr != nil) != test.isError {
+				t.Error("Unexpected error result.")
+				return
+			}
+			if apiErr == nil && (pagination.Limit != test.limit || pagination.Offset != test.offset) {
+				t.Error("Incorrect pagination.")
+			}
+		})
+	}
+}
+
+func TestSendPage(t *testing.T) {
+	// Multiple test cases
+	offset := 10
+	var tests = []struct {
+		name  string
+		page  *Page
+		links []string
+	}{
+		{"Offset pagination - middle page",
+			&Page{Items: []int{}, Total: 45, Limit: 10, Offset: &offset},
+			[]string{
+				`</v1/users?isActive=true&limit=10&offset=0>; rel="first"`,
+				`</v1/users?isActive=true&limit=10&offset=0>; rel="prev"`,
+				`</v1/users?isActive=true&limit=10&offset=20>; rel="next"`,
+				`</v1/users?isActive=true&limit=10&offset=40>; rel="last"`,
+			},
+		},
+		{"Cursor pagination",
+			&Page{Items: []int{}, Total: 45, Limit: 10, NextCursor: "abc.def"},
+			[]string{
+				`</v1/users?cursor=&isActive=true&limit=10>; rel="first"`,
+				`</v1/users?cursor=abc.def&isActive=true&limit=10>; rel="next"`,
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest("GET", "/v1/users?isActive=true&limit=10", nil)
+
+			SendPage(w, r, 200, test.page)
+			if w.Header().Get("Link") != strings.Join(test.links, ", ") {
+				t.Errorf("Incorrect Link header: %s", w.Header().Get("Link"))
+			}
+
+			page := make(map[string]interface{})
+			if err := json.Unmarshal(w.Body.Bytes(), &page); err != nil || page["total"] != float64(45) {
+				t.Error("Incorrect response body.")
+			}
+		})
+	}
+}
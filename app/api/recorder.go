@@ -0,0 +1,48 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// UnmatchedRoute - route template of requests not matching any route, e.g. 404 responses
+const UnmatchedRoute = "unmatched"
+
+// ResponseRecorder - records the status code and the size of the response written by a handler;
+// shared by the access log, metrics and tracing middlewares
+type ResponseRecorder struct {
+	http.ResponseWriter
+	// Status - status code of the response, 200 if the handler did not write one
+	Status int
+	// Bytes - size of the response body
+	Bytes int
+}
+
+// NewResponseRecorder - wraps the response writer
+func NewResponseRecorder(w http.ResponseWriter) *ResponseRecorder {
+	return &ResponseRecorder{ResponseWriter: w, Status: http.StatusOK}
+}
+
+func (r *ResponseRecorder) WriteHeader(status int) {
+	r.Status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *ResponseRecorder) Write(data []byte) (int, error) {
+	written, err := r.ResponseWriter.Write(data)
+	r.Bytes += written
+	return written, err
+}
+
+// RouteTemplate - returns the template of the route matched by the request (e.g. /v1/users/{id}),
+// or UnmatchedRoute; templates keep log fields, metric labels and span names bounded
+func RouteTemplate(r *http.Request) string {
+	if currentRoute := mux.CurrentRoute(r); currentRoute != nil {
+		if template, err := currentRoute.GetPathTemplate(); err == nil {
+			return template
+		}
+	}
+
+	return UnmatchedRoute
+}
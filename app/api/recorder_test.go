@@ -0,0 +1,54 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+func TestResponseRecorder(t *testing.T) {
+	t.Run("Status and size of the response", func(t *testing.T) {
+		recorder := NewResponseRecorder(httptest.NewRecorder())
+		if recorder.Status != http.StatusOK {
+			t.Error("Status should default to 200:", recorder.Status)
+		}
+
+		recorder.WriteHeader(http.StatusCreated)
+		recorder.Write([]byte("hello"))
+		recorder.Write([]byte("!"))
+		if recorder.Status != http.StatusCreated || recorder.Bytes != 6 {
+			t.Error("Incorrect recorded response:", recorder.Status, recorder.Bytes)
+		}
+	})
+}
+
+func TestRouteTemplate(t *testing.T) {
+	var route string
+	router := mux.NewRouter()
+	router.NotFoundHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route = RouteTemplate(r)
+	})
+	router.HandleFunc("/v1/users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		route = RouteTemplate(r)
+	})
+
+	// Multiple test cases
+	var tests = []struct {
+		name  string
+		path  string
+		route string
+	}{
+		{"Matched route", "/v1/users/42", "/v1/users/{id}"},
+		{"Unmatched route", "/unknown/path", UnmatchedRoute},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", test.path, nil))
+			if route != test.route {
+				t.Error("Incorrect route template:", route)
+			}
+		})
+	}
+}
@@ -0,0 +1,41 @@
+package api
+
+import (
+	"context"
+	"net/http"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+// RequestIDHeader - header carrying the request ID
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength - longer client provided request IDs are replaced
+const maxRequestIDLength = 128
+
+// requestIDKey - context key for the request ID
+type requestIDKey struct{}
+
+// RequestIDMiddleware - takes the request ID from the request header (or generates one),
+// stores it in the request context and echoes it in the response header
+func RequestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := r.Header.Get(RequestIDHeader)
+		if requestID == "" || len(requestID) > maxRequestIDLength {
+			requestID = uuid.NewV4().String()
+		}
+
+		w.Header().Set(RequestIDHeader, requestID)
+		ctx := context.WithValue(r.Context(), requestIDKey{}, requestID)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// RequestID - returns the ID of the request, if known
+func RequestID(r *http.Request) string {
+	if requestID, ok := r.Context().Value(requestIDKey{}).(string); ok {
+		return requestID
+	}
+
+	return r.Header.Get(RequestIDHeader)
+}
@@ -0,0 +1,41 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRequestIDMiddleware(t *testing.T) {
+	// Multiple test cases
+	var tests = []struct {
+		name      string
+		requestID string
+		generated bool
+	}{
+		{"Request ID from header", "test-request-id", false},
+		{"Generated request ID", "", true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var contextID string
+			handler := RequestIDMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				contextID = RequestID(r)
+			}))
+
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest("GET", "/", nil)
+			if test.requestID != "" {
+				r.Header.Set(RequestIDHeader, test.requestID)
+			}
+			handler.ServeHTTP(w, r)
+
+			if contextID == "" || contextID != w.Header().Get(RequestIDHeader) {
+				t.Error("Request ID should be stored in the context and echoed in the response.")
+			}
+			if !test.generated && contextID != test.requestID {
+				t.Error("Request ID from header should be kept.")
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+)
+
+// CodeInvalidParameter - error code sent when a query parameter is invalid
+const CodeInvalidParameter = "invalid_parameter"
+
+// SortField - column to sort by, as taken from a whitelist
+type SortField struct {
+	Column     string
+	Descending bool
+}
+
+// ParseSort - parses a sort expression such as "-created,lastName" (a leading "-" means descending).
+// Only fields present in the whitelist, mapping API field names to columns, are accepted.
+func ParseSort(expression string, whitelist map[string]string) ([]SortField, *Error) {
+	sortFields := make([]SortField, 0)
+	if expression == "" {
+		return sortFields, nil
+	}
+
+	seen := make(map[string]bool)
+	for _, field := range strings.Split(expression, ",") {
+		field = strings.TrimSpace(field)
+		descending := strings.HasPrefix(field, "-")
+		field = strings.TrimPrefix(strings.TrimPrefix(field, "-"), "+")
+
+		column, ok := whitelist[field]
+		if !ok || seen[column] {
+			return nil, NewError(http.StatusBadRequest, CodeInvalidParameter, "The sort expression is invalid.").
+				WithDetails(FieldError{Field: "sort", Code: "unsupported_sort_field", Message: "Sorting by '" + field + "' is not supported."})
+		}
+		seen[column] = true
+		sortFields = append(sortFields, SortField{Column: column, Descending: descending})
+	}
+
+	return sortFields, nil
+}
+
+// OrderBy - builds an ORDER BY clause (without the keyword) from the sort fields
+func OrderBy(sortFields []SortField) string {
+	clauses := make([]string, 0, len(sortFields))
+	for _, sortField := range sortFields {
+		direction := " ASC"
+		if sortField.Descending {
+			direction = " DESC"
+		}
+		clauses = append(clauses, sortField.Column+direction)
+	}
+
+	return strings.Join(clauses, ", ")
+}
@@ -0,0 +1,36 @@
+package api
+
+import (
+	"testing"
+)
+
+func TestParseSort(t *testing.T) {
+	whitelist := map[string]string{"created": "created", "lastName": "last_name"}
+
+	// Multiple test cases
+	var tests = []struct {
+		name       string
+		expression string
+		orderBy    string
+		isError    bool
+	}{
+		{"Empty expression", "", "", false},
+		{"Multiple fields", "-created,lastName", "created DESC, last_name ASC", false},
+		{"Explicit ascending", "+lastName", "last_name ASC", false},
+		{"Field not in whitelist", "password", "", true},
+		{"SQL injection attempt", "created;DROP TABLE user", "", true},
+		{"Duplicate field", "created,-created", "", true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sortFields, apiErr := ParseSort(test.expression, whitelist)
+			if (apiErr != nil) != test.isError {
+				t.Error("Unexpected error result.")
+				return
+			}
+			if apiErr == nil && OrderBy(sortFields) != test.orderBy {
+				t.Errorf("Expected %q, got %q", test.orderBy, OrderBy(sortFields))
+			}
+		})
+	}
+}
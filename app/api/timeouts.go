@@ -0,0 +1,34 @@
+package api
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// QueryTimeouts - time allowed for store operations; expired operations are cancelled
+type QueryTimeouts struct {
+	// Default - timeout of operations without their own timeout; zero means no timeout
+	Default time.Duration
+	// Operations - timeouts by operation, e.g. "user.list"; names are case insensitive
+	Operations map[string]time.Duration
+}
+
+// For - returns the timeout of the operation
+func (t QueryTimeouts) For(operation string) time.Duration {
+	if timeout, ok := t.Operations[strings.ToLower(operation)]; ok {
+		return timeout
+	}
+
+	return t.Default
+}
+
+// WithTimeout - returns a copy of the context cancelled after the timeout of the operation, if any
+func (t QueryTimeouts) WithTimeout(ctx context.Context, operation string) (context.Context, context.CancelFunc) {
+	timeout := t.For(operation)
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+
+	return context.WithTimeout(ctx, timeout)
+}
@@ -0,0 +1,47 @@
+package api
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestQueryTimeouts(t *testing.T) {
+	timeouts := QueryTimeouts{
+		Default:    5 * time.Second,
+		Operations: map[string]time.Duration{"user.list": 10 * time.Second, "user.purge": 0},
+	}
+
+	// Multiple test cases
+	var tests = []struct {
+		name      string
+		operation string
+		timeout   time.Duration
+	}{
+		{"Default timeout", "user.get", 5 * time.Second},
+		{"Operation timeout", "user.list", 10 * time.Second},
+		{"Case insensitive", "user.List", 10 * time.Second},
+		{"Disabled timeout", "user.purge", 0},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if timeout := timeouts.For(test.operation); timeout != test.timeout {
+				t.Errorf("Incorrect timeout for %s: %v", test.operation, timeout)
+			}
+		})
+	}
+
+	t.Run("Context deadline", func(t *testing.T) {
+		ctx, cancel := timeouts.WithTimeout(context.Background(), "user.get")
+		defer cancel()
+		if deadline, ok := ctx.Deadline(); !ok || time.Until(deadline) > 5*time.Second {
+			t.Error("Deadline not set.")
+		}
+
+		ctx, cancel = timeouts.WithTimeout(context.Background(), "user.purge")
+		defer cancel()
+		if _, ok := ctx.Deadline(); ok {
+			t.Error("Deadline set without timeout.")
+		}
+	})
+}
@@ -0,0 +1,123 @@
+package api
+
+import (
+	"net/http"
+	"net/mail"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// CodeValidationFailed - error code sent when the request payload fails validation
+const CodeValidationFailed = "validation_failed"
+
+// Rule - validation rule; returns a field error when the value is invalid
+type Rule func(value reflect.Value, param string) *FieldError
+
+// rules - registered validation rules, referenced by name in `validate` struct tags
+var rules = map[string]Rule{
+	"required": requiredRule,
+	"min":      minLengthRule,
+	"max":      maxLengthRule,
+	"email":    emailRule,
+}
+
+// RegisterRule - registers a validation rule, available to all resources
+func RegisterRule(name string, rule Rule) {
+	rules[name] = rule
+}
+
+// Validate - checks the struct fields against the rules declared in their `validate` tags,
+// e.g. `validate:"required,max=255"`. Returns a 422 error listing every failing field.
+func Validate(v interface{}) *Error {
+	value := reflect.Indirect(reflect.ValueOf(v))
+	if value.Kind() != reflect.Struct {
+		return nil
+	}
+
+	fieldErrors := make([]FieldError, 0)
+	valueType := value.Type()
+	for i := 0; i < valueType.NumField(); i++ {
+		field := valueType.Field(i)
+		tag, ok := field.Tag.Lookup("validate")
+		if !ok || tag == "" {
+			continue
+		}
+
+		for _, ruleDefinition := range strings.Split(tag, ",") {
+			name, param := ruleDefinition, ""
+			if index := strings.Index(ruleDefinition, "="); index >= 0 {
+				name, param = ruleDefinition[:index], ruleDefinition[index+1:]
+			}
+			rule, ok := rules[name]
+			if !ok {
+				panic("api: unknown validation rule " + name + " on field " + field.Name)
+			}
+
+			// Report only the first failing rule of each field
+			if fieldError := rule(value.Field(i), param); fieldError != nil {
+				fieldError.Field = fieldName(field)
+				fieldErrors = append(fieldErrors, *fieldError)
+				break
+			}
+		}
+	}
+
+	if len(fieldErrors) == 0 {
+		return nil
+	}
+
+	return NewError(http.StatusUnprocessableEntity, CodeValidationFailed, "The request payload is invalid.").
+		WithDetails(fieldErrors...)
+}
+
+// fieldName - name of the field as seen by the client
+func fieldName(field reflect.StructField) string {
+	name := strings.Split(field.Tag.Get("json"), ",")[0]
+	if name == "" || name == "-" {
+		return field.Name
+	}
+
+	return name
+}
+
+func requiredRule(value reflect.Value, param string) *FieldError {
+	if value.IsZero() || (value.Kind() == reflect.String && strings.TrimSpace(value.String()) == "") {
+		return &FieldError{Code: "required", Message: "The field is required."}
+	}
+
+	return nil
+}
+
+func minLengthRule(value reflect.Value, param string) *FieldError {
+	length, _ := strconv.Atoi(param)
+	if value.Kind() == reflect.String && utf8.RuneCountInString(value.String()) < length {
+		return &FieldError{Code: "min_length", Message: "The field must be at least " + param + " characters long."}
+	}
+
+	return nil
+}
+
+func maxLengthRule(value reflect.Value, param string) *FieldError {
+	length, _ := strconv.Atoi(param)
+	if value.Kind() == reflect.String && utf8.RuneCountInString(value.String()) > length {
+		return &FieldError{Code: "max_length", Message: "The field must be at most " + param + " characters long."}
+	}
+
+	return nil
+}
+
+func emailRule(value reflect.Value, param string) *FieldError {
+	if value.Kind() != reflect.String || value.String() == "" {
+		return nil
+	}
+
+	// Only bare addresses are accepted, without display names
+	address, err := mail.ParseAddress(value.String())
+	if err != nil || address.Address != value.String() {
+		return &FieldError{Code: "email", Message: "The field must be a valid email address."}
+	}
+
+	return nil
+}
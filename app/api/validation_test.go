@@ -0,0 +1,67 @@
+package api
+
+import (
+	"strings"
+	"testing"
+)
+
+// validatedResource - sample resource used for testing the validation rules
+type validatedResource struct {
+	Name  string `json:"name" validate:"required,min=2,max=5"`
+	Email string `json:"email,omitempty" validate:"email"`
+	Count int    `validate:"required"`
+	Notes string `json:"notes"`
+}
+
+func TestValidate(t *testing.T) {
+	// Multiple test cases
+	var tests = []struct {
+		name     string
+		resource validatedResource
+		failing  []string
+	}{
+		{"Valid resource",
+			validatedResource{Name: "abc", Email: "a.b@mail.test", Count: 1},
+			[]string{},
+		},
+		{"Missing required fields",
+			validatedResource{Name: "  "},
+			[]string{"name:required", "Count:required"},
+		},
+		{"Length limits",
+			validatedResource{Name: "a", Count: 1},
+			[]string{"name:min_length"},
+		},
+		{"Length limits - multi-byte characters",
+			validatedResource{Name: "ăîșțâ", Count: 1},
+			[]string{},
+		},
+		{"Too long and invalid email",
+			validatedResource{Name: "abcdef", Email: "Name <a.b@mail.test>", Count: 1},
+			[]string{"name:max_length", "email:email"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			apiErr := Validate(&test.resource)
+			if len(test.failing) == 0 {
+				if apiErr != nil {
+					t.Error("Unexpected validation error.")
+				}
+				return
+			}
+
+			if apiErr == nil || apiErr.Status != 422 || apiErr.Code != CodeValidationFailed {
+				t.Error("Validation error expected.")
+				return
+			}
+			failing := make([]string, 0)
+			for _, fieldError := range apiErr.Errors {
+				failing = append(failing, fieldError.Field+":"+fieldError.Code)
+			}
+			if strings.Join(failing, ",") != strings.Join(test.failing, ",") {
+				t.Errorf("Expected failing fields %v, got %v", test.failing, failing)
+			}
+		})
+	}
+}
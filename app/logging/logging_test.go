@@ -0,0 +1,83 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestParseLevel(t *testing.T) {
+	// Multiple test cases
+	var tests = []struct {
+		name    string
+		level   string
+		want    slog.Level
+		isError bool
+	}{
+		{"Debug", "debug", slog.LevelDebug, false},
+		{"Upper case", "WARN", slog.LevelWarn, false},
+		{"Unknown", "verbose", slog.LevelInfo, true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			level, err := ParseLevel(test.level)
+			if (err != nil) != test.isError || (err == nil && level != test.want) {
+				t.Errorf("Incorrect level for %s: %v, %v", test.level, level, err)
+			}
+		})
+	}
+}
+
+func TestNew(t *testing.T) {
+	t.Run("JSON format", func(t *testing.T) {
+		var output bytes.Buffer
+		logger, err := New(&output, FormatJSON, slog.LevelInfo)
+		if err != nil {
+			t.Fatal("Logger creation failed:", err)
+		}
+		logger.Debug("hidden")
+		logger.Info("request", "status", 200)
+
+		entry := make(map[string]interface{})
+		if err := json.Unmarshal(output.Bytes(), &entry); err != nil {
+			t.Fatal("Expected a single JSON line:", output.String())
+		}
+		if entry["level"] != "INFO" || entry["msg"] != "request" || entry["status"] != float64(200) {
+			t.Error("Incorrect log entry:", output.String())
+		}
+	})
+
+	t.Run("Logfmt format", func(t *testing.T) {
+		var output bytes.Buffer
+		logger, err := New(&output, FormatLogfmt, slog.LevelInfo)
+		if err != nil {
+			t.Fatal("Logger creation failed:", err)
+		}
+		logger.Warn("slow query", "method", "List")
+
+		if !strings.Contains(output.String(), `level=WARN msg="slow query" method=List`) {
+			t.Error("Incorrect log entry:", output.String())
+		}
+	})
+
+	t.Run("Unknown format", func(t *testing.T) {
+		if _, err := New(&bytes.Buffer{}, "xml", slog.LevelInfo); err == nil {
+			t.Error("Logger creation should fail on unknown format")
+		}
+	})
+}
+
+func TestFromContext(t *testing.T) {
+	t.Run("Logger in context", func(t *testing.T) {
+		logger := slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil))
+		if FromContext(NewContext(context.Background(), logger)) != logger {
+			t.Error("Logger not carried by the context.")
+		}
+		if FromContext(context.Background()) != slog.Default() {
+			t.Error("Default logger expected without logger in context.")
+		}
+	})
+}
@@ -0,0 +1,104 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"sample-rest-api/app/api"
+)
+
+// otherMethod - method label of requests with a non standard method
+const otherMethod = "other"
+
+// standardMethods - methods labelled as sent; the method is client controlled, so others are grouped
+var standardMethods = map[string]bool{
+	http.MethodGet:     true,
+	http.MethodHead:    true,
+	http.MethodPost:    true,
+	http.MethodPut:     true,
+	http.MethodPatch:   true,
+	http.MethodDelete:  true,
+	http.MethodConnect: true,
+	http.MethodOptions: true,
+	http.MethodTrace:   true,
+}
+
+var (
+	// requestsTotal - handled HTTP requests
+	requestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "Number of handled HTTP requests.",
+	}, []string{"route", "method", "status"})
+
+	// requestDuration - HTTP request latency
+	requestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "Latency of HTTP requests.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"route", "method", "status"})
+
+	// requestsInFlight - HTTP requests being handled
+	requestsInFlight = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "http_requests_in_flight",
+		Help: "Number of HTTP requests being handled.",
+	})
+
+	// queryDuration - duration of the store methods
+	queryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "db_query_duration_seconds",
+		Help:    "Duration of the database queries run by store methods.",
+		Buckets: []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"store", "method"})
+)
+
+func init() {
+	prometheus.MustRegister(requestsTotal, requestDuration, requestsInFlight, queryDuration)
+}
+
+// Handler - serves the registered metrics in the Prometheus text format
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// RegisterDB - exports the connection pool statistics (sql.DBStats) of the database handle
+func RegisterDB(db *sqlx.DB, name string) error {
+	return prometheus.Register(collectors.NewDBStatsCollector(db.DB, name))
+}
+
+// ObserveQuery - records the duration of a store method started at the given time;
+// meant to be deferred: defer metrics.ObserveQuery("user", "List", time.Now())
+func ObserveQuery(store string, method string, start time.Time) {
+	queryDuration.WithLabelValues(store, method).Observe(time.Since(start).Seconds())
+}
+
+// Middleware - counts requests and observes their latency, labelled by route template, method and status.
+// The route template (e.g. /v1/users/{id}) keeps the label cardinality bounded.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestsInFlight.Inc()
+		defer requestsInFlight.Dec()
+
+		start := time.Now()
+		recorder := api.NewResponseRecorder(w)
+		next.ServeHTTP(recorder, r)
+
+		labels := prometheus.Labels{"route": api.RouteTemplate(r), "method": methodLabel(r.Method), "status": strconv.Itoa(recorder.Status)}
+		requestsTotal.With(labels).Inc()
+		requestDuration.With(labels).Observe(time.Since(start).Seconds())
+	})
+}
+
+// methodLabel - the method label of the request method, bounded to the standard methods
+func methodLabel(method string) string {
+	if standardMethods[method] {
+		return method
+	}
+
+	return otherMethod
+}
@@ -0,0 +1,99 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gorilla/mux"
+	"github.com/jmoiron/sqlx"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"sample-rest-api/app/api"
+)
+
+func TestMiddleware(t *testing.T) {
+	router := mux.NewRouter()
+	router.NotFoundHandler = Middleware(http.NotFoundHandler())
+	router.Use(Middleware)
+	router.HandleFunc("/v1/users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		if testutil.ToFloat64(requestsInFlight) != 1 {
+			t.Error("In-flight request not counted.")
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}).Methods("DELETE")
+
+	t.Run("Route template label", func(t *testing.T) {
+		before := testutil.ToFloat64(requestsTotal.WithLabelValues("/v1/users/{id}", "DELETE", "204"))
+		for _, id := range []string{"1", "2"} {
+			router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("DELETE", "/v1/users/"+id, nil))
+		}
+
+		if testutil.ToFloat64(requestsTotal.WithLabelValues("/v1/users/{id}", "DELETE", "204"))-before != 2 {
+			t.Error("Requests not counted by route template.")
+		}
+		if testutil.ToFloat64(requestsInFlight) != 0 {
+			t.Error("In-flight requests not released.")
+		}
+	})
+
+	t.Run("Unmatched route label", func(t *testing.T) {
+		before := testutil.ToFloat64(requestsTotal.WithLabelValues(api.UnmatchedRoute, "GET", "404"))
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/unknown/path", nil))
+
+		if testutil.ToFloat64(requestsTotal.WithLabelValues(api.UnmatchedRoute, "GET", "404"))-before != 1 {
+			t.Error("Unmatched requests not counted.")
+		}
+	})
+
+	t.Run("Non standard method label", func(t *testing.T) {
+		before := testutil.ToFloat64(requestsTotal.WithLabelValues(api.UnmatchedRoute, otherMethod, "404"))
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("X-RANDOM-1234", "/unknown/path", nil))
+
+		if testutil.ToFloat64(requestsTotal.WithLabelValues(api.UnmatchedRoute, otherMethod, "404"))-before != 1 {
+			t.Error("Non standard methods should be labelled as other.")
+		}
+	})
+}
+
+func TestObserveQuery(t *testing.T) {
+	t.Run("Store method duration", func(t *testing.T) {
+		ObserveQuery("test", "Get", time.Now().Add(-time.Second))
+
+		if testutil.CollectAndCount(queryDuration, "db_query_duration_seconds") == 0 {
+			t.Error("Query duration not observed.")
+		}
+	})
+}
+
+func TestHandler(t *testing.T) {
+	t.Run("Prometheus text format", func(t *testing.T) {
+		mockDB, _, err := sqlmock.New()
+		if err != nil {
+			t.Fatal("Could not create database mock.")
+		}
+		defer mockDB.Close()
+		if err := RegisterDB(sqlx.NewDb(mockDB, "sqlmock"), "test"); err != nil {
+			t.Fatal("Could not register pool metrics:", err)
+		}
+		// Collectors are unregistered by their descriptors, so the test can run again on the global registry
+		t.Cleanup(func() {
+			prometheus.Unregister(collectors.NewDBStatsCollector(mockDB, "test"))
+		})
+		ObserveQuery("test", "List", time.Now())
+
+		w := httptest.NewRecorder()
+		Handler().ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+		body := w.Body.String()
+		for _, name := range []string{"db_query_duration_seconds_bucket", "go_sql_open_connections", "http_requests_in_flight"} {
+			if !strings.Contains(body, name) {
+				t.Error("Missing metric:", name)
+			}
+		}
+	})
+}
@@ -0,0 +1,151 @@
+package tracing
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+
+	"sample-rest-api/app/api"
+)
+
+// instrumentationName - name of the tracer creating the spans of this package
+const instrumentationName = "sample-rest-api"
+
+// Exporters
+const (
+	// ExporterNone - spans are not recorded; incoming trace context is still propagated
+	ExporterNone = "none"
+	// ExporterStdout - spans are written to the standard output as JSON
+	ExporterStdout = "stdout"
+	// ExporterFile - spans are appended to a file as JSON
+	ExporterFile = "file"
+	// ExporterOTLP - spans are sent to an OTLP/HTTP collector
+	ExporterOTLP = "otlp"
+)
+
+// Options - tracing settings
+type Options struct {
+	// Exporter - one of the exporters
+	Exporter string
+	// Endpoint - OTLP/HTTP collector URL, e.g. http://localhost:4318
+	Endpoint string
+	// File - path of the file exporter output
+	File string
+	// ServiceName - service.name resource attribute
+	ServiceName string
+	// SampleRatio - fraction of the traces started here which are sampled; sampled parent spans are always followed
+	SampleRatio float64
+}
+
+// Setup - installs the global tracer provider and the W3C trace context propagator.
+// The returned function flushes the pending spans and stops the exporter.
+func Setup(ctx context.Context, options *Options) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	var exporter sdktrace.SpanExporter
+	var output io.Closer
+	var err error
+	switch options.Exporter {
+	case "", ExporterNone:
+		return func(context.Context) error { return nil }, nil
+	case ExporterStdout:
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+	case ExporterFile:
+		var file *os.File
+		file, err = os.OpenFile(options.File, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return nil, fmt.Errorf("cannot open trace file: %w", err)
+		}
+		output = file
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(file))
+	case ExporterOTLP:
+		exporter, err = otlptracehttp.New(ctx, otlptracehttp.WithEndpointURL(options.Endpoint))
+	default:
+		return nil, fmt.Errorf("unknown trace exporter %q", options.Exporter)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(options.SampleRatio))),
+		sdktrace.WithResource(resource.NewSchemaless(attribute.String("service.name", options.ServiceName))),
+	)
+	otel.SetTracerProvider(provider)
+
+	return func(ctx context.Context) error {
+		err := provider.Shutdown(ctx)
+		if output != nil {
+			output.Close()
+		}
+		return err
+	}, nil
+}
+
+// tracer - returns the tracer of the global provider, so providers installed later are used
+func tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
+
+// Middleware - continues the trace of the traceparent header (or starts a new one) with a server span
+// named by the method and route template, e.g. "GET /v1/users/{id}"
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := api.RouteTemplate(r)
+
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		ctx, span := tracer().Start(ctx, r.Method+" "+route,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				attribute.String("http.request.method", r.Method),
+				attribute.String("http.route", route),
+				attribute.String("url.path", r.URL.Path),
+			),
+		)
+		defer span.End()
+
+		recorder := api.NewResponseRecorder(w)
+		next.ServeHTTP(recorder, r.WithContext(ctx))
+
+		span.SetAttributes(attribute.Int("http.response.status_code", recorder.Status))
+		if recorder.Status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, strconv.Itoa(recorder.Status))
+		}
+	})
+}
+
+// StartQuery - starts a client span for a database query, e.g. StartQuery(ctx, "mysql", "user.List", query)
+func StartQuery(ctx context.Context, system string, name string, query string) (context.Context, trace.Span) {
+	return tracer().Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system", system),
+			attribute.String("db.statement", query),
+		),
+	)
+}
+
+// EndQuery - records the query error, if any, and ends the span; missing rows are not an error
+func EndQuery(span trace.Span, err error) {
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,120 @@
+package tracing
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// recordSpans - installs a tracer provider recording the ended spans
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		provider.Shutdown(context.Background())
+	})
+
+	return recorder
+}
+
+func TestMiddleware(t *testing.T) {
+	t.Run("Server span named by route template", func(t *testing.T) {
+		recorder := recordSpans(t)
+		router := mux.NewRouter()
+		router.Use(Middleware)
+		router.HandleFunc("/v1/users/{id}", func(w http.ResponseWriter, r *http.Request) {
+			_, span := StartQuery(r.Context(), "mysql", "userStore.Get", "SELECT 1")
+			EndQuery(span, nil)
+			w.WriteHeader(http.StatusInternalServerError)
+		})
+
+		request := httptest.NewRequest("GET", "/v1/users/42", nil)
+		request.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+		router.ServeHTTP(httptest.NewRecorder(), request)
+
+		spans := recorder.Ended()
+		if len(spans) != 2 {
+			t.Fatal("Expected a query span and a server span, got", len(spans))
+		}
+		query, server := spans[0], spans[1]
+		if server.Name() != "GET /v1/users/{id}" {
+			t.Error("Incorrect span name:", server.Name())
+		}
+		if server.SpanContext().TraceID().String() != "4bf92f3577b34da6a3ce929d0e0e4736" ||
+			server.Parent().SpanID().String() != "00f067aa0ba902b7" {
+			t.Error("Trace context not propagated from traceparent.")
+		}
+		if server.Status().Code != codes.Error {
+			t.Error("Server errors should set the span status.")
+		}
+		if query.Parent().SpanID() != server.SpanContext().SpanID() {
+			t.Error("Query span should be a child of the server span.")
+		}
+	})
+}
+
+func TestEndQuery(t *testing.T) {
+	// Multiple test cases
+	var tests = []struct {
+		name   string
+		err    error
+		status codes.Code
+	}{
+		{"Success", nil, codes.Unset},
+		{"No rows", sql.ErrNoRows, codes.Unset},
+		{"Failure", errors.New("connection reset"), codes.Error},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			recorder := recordSpans(t)
+			_, span := StartQuery(context.Background(), "mysql", "userStore.List", "SELECT 1")
+			EndQuery(span, test.err)
+
+			spans := recorder.Ended()
+			if len(spans) != 1 || spans[0].Status().Code != test.status {
+				t.Error("Incorrect query span status.")
+			}
+		})
+	}
+}
+
+func TestSetup(t *testing.T) {
+	t.Run("File exporter", func(t *testing.T) {
+		filename := filepath.Join(t.TempDir(), "traces.json")
+		shutdown, err := Setup(context.Background(), &Options{Exporter: ExporterFile, File: filename, ServiceName: "test", SampleRatio: 1})
+		if err != nil {
+			t.Fatal("Setup failed:", err)
+		}
+		_, span := StartQuery(context.Background(), "mysql", "userStore.List", "SELECT 1")
+		EndQuery(span, nil)
+		if err := shutdown(context.Background()); err != nil {
+			t.Fatal("Shutdown failed:", err)
+		}
+
+		content, err := os.ReadFile(filename)
+		if err != nil || !strings.Contains(string(content), "userStore.List") {
+			t.Error("Span not exported to the file:", string(content))
+		}
+	})
+
+	t.Run("Unknown exporter", func(t *testing.T) {
+		if _, err := Setup(context.Background(), &Options{Exporter: "zipkin"}); err == nil {
+			t.Error("Setup should fail on unknown exporter")
+		}
+	})
+}
@@ -0,0 +1,357 @@
+package user
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+
+	"sample-rest-api/app/api"
+)
+
+// MemoryStore - UserStore keeping the users in memory, for tests and development; safe for concurrent use.
+// It mirrors the SQL store: unique emails, soft deletes, filters, sorting and pagination.
+type MemoryStore struct {
+	mu     sync.RWMutex
+	users  map[string]*User
+	lastID int
+}
+
+// NewMemoryStore - creates an empty in-memory user store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{users: make(map[string]*User)}
+}
+
+// now - current time at the precision of the SQL store
+func now() time.Time {
+	return time.Now().UTC().Truncate(time.Second)
+}
+
+// List - store method for listing users
+func (ms *MemoryStore) List(ctx context.Context, options *ListOptions) ([]User, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	users := ms.filter(options, true)
+	sortUsers(users, options)
+
+	// Apply the pagination
+	page := make([]User, 0)
+	for i := options.Offset; i < len(users) && len(page) < options.Limit; i++ {
+		page = append(page, users[i])
+	}
+
+	return page, nil
+}
+
+// Count - store method for counting the users matching the list filters
+func (ms *MemoryStore) Count(ctx context.Context, options *ListOptions) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	// The cursor position is not a filter, so it is not counted against
+	return int64(len(ms.filter(options, false))), nil
+}
+
+// Create - store method for creating a user; the user is populated with the stored values
+func (ms *MemoryStore) Create(ctx context.Context, user *User) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	if ms.emailTaken(user.Email, "") {
+		return &ConflictError{Field: "email"}
+	}
+
+	ms.lastID++
+	created := now()
+	stored := &User{
+		ID:        ms.lastID,
+		UUID:      uuid.NewV4(),
+		FirstName: user.FirstName,
+		LastName:  user.LastName,
+		Email:     user.Email,
+		IsActive:  user.IsActive,
+		Created:   created,
+		Modified:  created,
+	}
+	ms.users[stored.UUID.String()] = stored
+	*user = *stored
+
+	return nil
+}
+
+// Get - store method for fetching a user; soft deleted users are only returned if includeDeleted is set
+func (ms *MemoryStore) Get(ctx context.Context, userID string, includeDeleted bool) (*User, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	user, ok := ms.users[userID]
+	if !ok || (user.DeletedAt != nil && !includeDeleted) {
+		return nil, sql.ErrNoRows
+	}
+
+	// Return a copy, so the stored user cannot be modified
+	found := *user
+	return &found, nil
+}
+
+// Update - store method for updating the given columns of a user
+func (ms *MemoryStore) Update(ctx context.Context, userID string, changes map[string]interface{}) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	user, ok := ms.users[userID]
+	if !ok || user.DeletedAt != nil {
+		return sql.ErrNoRows
+	}
+	if email, ok := changes["email"].(string); ok && ms.emailTaken(email, userID) {
+		return &ConflictError{Field: "email"}
+	}
+
+	// Only the whitelisted columns are applied
+	updated := *user
+	for _, column := range updatableColumns {
+		value, ok := changes[column]
+		if !ok {
+			continue
+		}
+		switch column {
+		case "first_name":
+			updated.FirstName, _ = value.(string)
+		case "last_name":
+			updated.LastName, _ = value.(string)
+		case "email":
+			updated.Email, _ = value.(string)
+		case "is_active":
+			updated.IsActive, _ = value.(bool)
+		}
+	}
+	updated.Modified = now()
+	ms.users[userID] = &updated
+
+	return nil
+}
+
+// Delete - store method for soft deleting a user; returns sql.ErrNoRows for unknown or already deleted users
+func (ms *MemoryStore) Delete(ctx context.Context, userID string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	user, ok := ms.users[userID]
+	if !ok || user.DeletedAt != nil {
+		return sql.ErrNoRows
+	}
+	deleted := *user
+	deletedAt := now()
+	deleted.DeletedAt = &deletedAt
+	deleted.Modified = deletedAt
+	ms.users[userID] = &deleted
+
+	return nil
+}
+
+// Restore - store method for restoring a soft deleted user; restoring a user which is not deleted has no effect
+func (ms *MemoryStore) Restore(ctx context.Context, userID string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	user, ok := ms.users[userID]
+	if !ok || user.DeletedAt == nil {
+		return nil
+	}
+	restored := *user
+	restored.DeletedAt = nil
+	restored.Modified = now()
+	ms.users[userID] = &restored
+
+	return nil
+}
+
+// Purge - store method for permanently deleting a user, soft deleted or not
+func (ms *MemoryStore) Purge(ctx context.Context, userID string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	if _, ok := ms.users[userID]; !ok {
+		return sql.ErrNoRows
+	}
+	delete(ms.users, userID)
+
+	return nil
+}
+
+// emailTaken - tells whether another user has the email; emails are case insensitive.
+// Soft deleted users keep their email until purged, like with the unique key of the SQL store.
+func (ms *MemoryStore) emailTaken(email string, userID string) bool {
+	for id, user := range ms.users {
+		if id != userID && strings.EqualFold(user.Email, email) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// filter - returns copies of the users matching the filters, and the cursor position if withCursor is set
+func (ms *MemoryStore) filter(options *ListOptions, withCursor bool) []User {
+	users := make([]User, 0)
+	for _, user := range ms.users {
+		if options.matches(user) && (!withCursor || options.after(user)) {
+			users = append(users, *user)
+		}
+	}
+
+	return users
+}
+
+// matches - tells whether the user matches the filters; the in-memory counterpart of where
+func (options *ListOptions) matches(user *User) bool {
+	if !options.IncludeDeleted && user.DeletedAt != nil {
+		return false
+	}
+	if options.IsActive != nil && user.IsActive != *options.IsActive {
+		return false
+	}
+	if options.Email != "" && !strings.EqualFold(user.Email, options.Email) {
+		return false
+	}
+	if options.Search != "" {
+		prefix := strings.ToLower(options.Search)
+		if !strings.HasPrefix(strings.ToLower(user.FirstName), prefix) &&
+			!strings.HasPrefix(strings.ToLower(user.LastName), prefix) &&
+			!strings.HasPrefix(strings.ToLower(user.Email), prefix) {
+			return false
+		}
+	}
+	if options.CreatedAfter != nil && !user.Created.After(*options.CreatedAfter) {
+		return false
+	}
+	if options.CreatedBefore != nil && !user.Created.Before(*options.CreatedBefore) {
+		return false
+	}
+
+	return true
+}
+
+// after - tells whether the user follows the cursor position, if any
+func (options *ListOptions) after(user *User) bool {
+	if options.After == nil {
+		return true
+	}
+
+	comparison := compareTimes(user.Created, options.After.Created)
+	if comparison == 0 {
+		comparison = compareInts(user.ID, options.After.ID)
+	}
+	if options.After.Descending {
+		return comparison < 0
+	}
+
+	return comparison > 0
+}
+
+// sortUsers - sorts the users like orderBy: by the sort fields, then by ID in the direction of the last one
+func sortUsers(users []User, options *ListOptions) {
+	sortFields := options.Sort
+	if len(sortFields) == 0 {
+		sortFields = defaultSort
+	}
+	tieBreaker := api.SortField{Column: "id", Descending: sortFields[len(sortFields)-1].Descending}
+	sortFields = append(sortFields[:len(sortFields):len(sortFields)], tieBreaker)
+
+	sort.SliceStable(users, func(i, j int) bool {
+		for _, field := range sortFields {
+			comparison := compareUsers(&users[i], &users[j], field.Column)
+			if comparison == 0 {
+				continue
+			}
+			if field.Descending {
+				return comparison > 0
+			}
+			return comparison < 0
+		}
+		return false
+	})
+}
+
+// compareUsers - compares the column of two users: -1 if a sorts first, 1 if b sorts first, 0 if equal.
+// Strings are compared case insensitively, like the default MySQL collation.
+func compareUsers(a *User, b *User, column string) int {
+	switch column {
+	case "first_name":
+		return strings.Compare(strings.ToLower(a.FirstName), strings.ToLower(b.FirstName))
+	case "last_name":
+		return strings.Compare(strings.ToLower(a.LastName), strings.ToLower(b.LastName))
+	case "email":
+		return strings.Compare(strings.ToLower(a.Email), strings.ToLower(b.Email))
+	case "is_active":
+		return compareInts(boolToInt(a.IsActive), boolToInt(b.IsActive))
+	case "created":
+		return compareTimes(a.Created, b.Created)
+	case "modified":
+		return compareTimes(a.Modified, b.Modified)
+	default:
+		return compareInts(a.ID, b.ID)
+	}
+}
+
+// compareInts - three-way comparison of integers
+func compareInts(a int, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// compareTimes - three-way comparison of times
+func compareTimes(a time.Time, b time.Time) int {
+	switch {
+	case a.Before(b):
+		return -1
+	case a.After(b):
+		return 1
+	default:
+		return 0
+	}
+}
+
+// boolToInt - false sorts before true
+func boolToInt(value bool) int {
+	if value {
+		return 1
+	}
+
+	return 0
+}
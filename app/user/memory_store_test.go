@@ -0,0 +1,246 @@
+package user
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync"
+	"testing"
+
+	"sample-rest-api/app/api"
+)
+
+// memoryStoreWithUsers - returns a memory store holding the given users
+func memoryStoreWithUsers(t *testing.T, users ...User) *MemoryStore {
+	store := NewMemoryStore()
+	for i := range users {
+		if err := store.Create(context.Background(), &users[i]); err != nil {
+			t.Fatal("Could not create user:", err)
+		}
+	}
+
+	return store
+}
+
+func TestMemoryStoreCreate(t *testing.T) {
+	t.Run("Create user", func(t *testing.T) {
+		store := NewMemoryStore()
+		user := &User{FirstName: "First", LastName: "Last", Email: "first.last@mail.test", IsActive: true}
+		if err := store.Create(context.Background(), user); err != nil {
+			t.Fatal("Unexpected error:", err)
+		}
+		if user.ID != 1 || user.UUID.String() == "" || user.Created.IsZero() {
+			t.Error("Created user not populated.")
+		}
+
+		found, err := store.Get(context.Background(), user.UUID.String(), false)
+		if err != nil || found.Email != user.Email {
+			t.Error("Created user not stored.")
+		}
+	})
+
+	t.Run("Create user - duplicate email", func(t *testing.T) {
+		store := memoryStoreWithUsers(t, User{Email: "first.last@mail.test"})
+		err := store.Create(context.Background(), &User{Email: "First.Last@mail.test"})
+		var conflictErr *ConflictError
+		if !errors.As(err, &conflictErr) || conflictErr.Field != "email" {
+			t.Error("Expected an email conflict, got:", err)
+		}
+	})
+}
+
+func TestMemoryStoreGet(t *testing.T) {
+	t.Run("Get user - copy", func(t *testing.T) {
+		store := memoryStoreWithUsers(t, User{FirstName: "First"})
+		userID := firstUserID(store)
+
+		found, _ := store.Get(context.Background(), userID, false)
+		found.FirstName = "Changed"
+		found, _ = store.Get(context.Background(), userID, false)
+		if found.FirstName != "First" {
+			t.Error("Stored user modified through a returned user.")
+		}
+	})
+
+	t.Run("Get user - unknown", func(t *testing.T) {
+		if _, err := NewMemoryStore().Get(context.Background(), "unknown", true); err != sql.ErrNoRows {
+			t.Error("Expected sql.ErrNoRows, got:", err)
+		}
+	})
+
+	t.Run("Get user - cancelled context", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		if _, err := NewMemoryStore().Get(ctx, "unknown", false); err != context.Canceled {
+			t.Error("Expected context.Canceled, got:", err)
+		}
+	})
+}
+
+func TestMemoryStoreList(t *testing.T) {
+	active, inactive := true, false
+	newStore := func(t *testing.T) *MemoryStore {
+		return memoryStoreWithUsers(t,
+			User{FirstName: "Alice", LastName: "Smith", Email: "alice@mail.test", IsActive: true},
+			User{FirstName: "Bob", LastName: "Jones", Email: "bob@mail.test", IsActive: false},
+			User{FirstName: "Carol", LastName: "Allen", Email: "carol@mail.test", IsActive: true},
+		)
+	}
+
+	// Multiple test cases
+	var tests = []struct {
+		name    string
+		options *ListOptions
+		emails  []string
+	}{
+		{"Default sort", &ListOptions{Limit: 10}, []string{"alice@mail.test", "bob@mail.test", "carol@mail.test"}},
+		{"Active", &ListOptions{Limit: 10, IsActive: &active}, []string{"alice@mail.test", "carol@mail.test"}},
+		{"Inactive", &ListOptions{Limit: 10, IsActive: &inactive}, []string{"bob@mail.test"}},
+		{"Email", &ListOptions{Limit: 10, Email: "BOB@mail.test"}, []string{"bob@mail.test"}},
+		{"Search", &ListOptions{Limit: 10, Search: "al"}, []string{"alice@mail.test", "carol@mail.test"}},
+		{"Sorted",
+			&ListOptions{Limit: 10, Sort: []api.SortField{{Column: "last_name", Descending: true}}},
+			[]string{"alice@mail.test", "bob@mail.test", "carol@mail.test"},
+		},
+		{"Limit and offset", &ListOptions{Limit: 1, Offset: 1}, []string{"bob@mail.test"}},
+		{"Offset past the end", &ListOptions{Limit: 10, Offset: 5}, []string{}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			users, err := newStore(t).List(context.Background(), test.options)
+			if err != nil {
+				t.Fatal("Unexpected error:", err)
+			}
+			if len(users) != len(test.emails) {
+				t.Fatalf("Expected %d users, got %d.", len(test.emails), len(users))
+			}
+			for i, user := range users {
+				if user.Email != test.emails[i] {
+					t.Errorf("Expected %s at position %d, got %s.", test.emails[i], i, user.Email)
+				}
+			}
+		})
+	}
+
+	t.Run("Keyset pagination", func(t *testing.T) {
+		store := newStore(t)
+		first, _ := store.List(context.Background(), &ListOptions{Limit: 1, Sort: defaultSort})
+		options := &ListOptions{Limit: 10, Sort: defaultSort, Keyset: true, After: newListCursor(&first[0], &ListOptions{Sort: defaultSort})}
+		rest, _ := store.List(context.Background(), options)
+		if len(rest) != 2 || rest[0].Email != "bob@mail.test" {
+			t.Error("Incorrect page after the cursor:", rest)
+		}
+
+		// The cursor is not a filter
+		count, _ := store.Count(context.Background(), options)
+		if count != 3 {
+			t.Error("Expected a count of 3, got:", count)
+		}
+	})
+}
+
+func TestMemoryStoreUpdate(t *testing.T) {
+	t.Run("Update user", func(t *testing.T) {
+		store := memoryStoreWithUsers(t, User{FirstName: "First", Email: "first@mail.test"})
+		userID := firstUserID(store)
+
+		err := store.Update(context.Background(), userID, map[string]interface{}{"first_name": "Changed", "is_active": true, "id": 5})
+		if err != nil {
+			t.Fatal("Unexpected error:", err)
+		}
+		found, _ := store.Get(context.Background(), userID, false)
+		if found.FirstName != "Changed" || !found.IsActive || found.ID != 1 {
+			t.Error("Incorrect update:", found)
+		}
+	})
+
+	t.Run("Update user - duplicate email", func(t *testing.T) {
+		store := memoryStoreWithUsers(t, User{Email: "first@mail.test"}, User{Email: "second@mail.test"})
+		userID := firstUserID(store)
+
+		err := store.Update(context.Background(), userID, map[string]interface{}{"email": "second@mail.test"})
+		var conflictErr *ConflictError
+		if !errors.As(err, &conflictErr) {
+			t.Error("Expected an email conflict, got:", err)
+		}
+	})
+}
+
+func TestMemoryStoreDelete(t *testing.T) {
+	t.Run("Delete, restore and purge user", func(t *testing.T) {
+		store := memoryStoreWithUsers(t, User{Email: "first@mail.test"})
+		userID := firstUserID(store)
+		ctx := context.Background()
+
+		if err := store.Delete(ctx, userID); err != nil {
+			t.Fatal("Unexpected error:", err)
+		}
+		if err := store.Delete(ctx, userID); err != sql.ErrNoRows {
+			t.Error("Deleting a deleted user should fail, got:", err)
+		}
+		if _, err := store.Get(ctx, userID, false); err != sql.ErrNoRows {
+			t.Error("Deleted user should not be found.")
+		}
+		if err := store.Update(ctx, userID, map[string]interface{}{"first_name": "Changed"}); err != sql.ErrNoRows {
+			t.Error("Deleted user should not be updated.")
+		}
+		if count, _ := store.Count(ctx, &ListOptions{IncludeDeleted: true}); count != 1 {
+			t.Error("Deleted user should be listed with IncludeDeleted.")
+		}
+		var conflictErr *ConflictError
+		if err := store.Create(ctx, &User{Email: "FIRST@mail.test"}); !errors.As(err, &conflictErr) {
+			t.Error("Deleted user should keep its email until purged, got:", err)
+		}
+
+		if err := store.Restore(ctx, userID); err != nil {
+			t.Fatal("Unexpected error:", err)
+		}
+		if _, err := store.Get(ctx, userID, false); err != nil {
+			t.Error("Restored user should be found.")
+		}
+
+		if err := store.Purge(ctx, userID); err != nil {
+			t.Fatal("Unexpected error:", err)
+		}
+		if _, err := store.Get(ctx, userID, true); err != sql.ErrNoRows {
+			t.Error("Purged user should not be found.")
+		}
+		if err := store.Purge(ctx, userID); err != sql.ErrNoRows {
+			t.Error("Purging an unknown user should fail, got:", err)
+		}
+		if err := store.Create(ctx, &User{Email: "first@mail.test"}); err != nil {
+			t.Error("Email of a purged user should be reusable:", err)
+		}
+	})
+}
+
+func TestMemoryStoreConcurrency(t *testing.T) {
+	t.Run("Concurrent creates", func(t *testing.T) {
+		store := NewMemoryStore()
+		var wg sync.WaitGroup
+		for i := 0; i < 50; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				store.Create(context.Background(), &User{Email: string(rune('a'+i%26)) + string(rune('a'+i/26)) + "@mail.test"})
+				store.List(context.Background(), &ListOptions{Limit: 10})
+			}(i)
+		}
+		wg.Wait()
+
+		if count, _ := store.Count(context.Background(), &ListOptions{}); count != 50 {
+			t.Error("Expected 50 users, got:", count)
+		}
+	})
+}
+
+// firstUserID - returns the UUID of the user with ID 1
+func firstUserID(store *MemoryStore) string {
+	for key, user := range store.users {
+		if user.ID == 1 {
+			return key
+		}
+	}
+
+	return ""
+}
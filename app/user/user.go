@@ -8,12 +8,75 @@ import (
 
 // User model
 type User struct {
-	ID        int       `db:"id" json:"-"`
-	UUID      uuid.UUID `db:"uuid" json:"uuid"` // UUID field used to avoid exposing auto increment PKs
-	FirstName string    `db:"first_name" json:"firstName"`
-	LastName  string    `db:"last_name" json:"lastName"`
-	Email     string    `db:"email" json:"email"`
-	IsActive  bool      `db:"is_active" json:"isActive"`
-	Created   time.Time `db:"created" json:"created"`
-	Modified  time.Time `db:"modified" json:"modified"`
+	ID        int        `db:"id" json:"-"`
+	UUID      uuid.UUID  `db:"uuid" json:"uuid"` // UUID field used to avoid exposing auto increment PKs
+	FirstName string     `db:"first_name" json:"firstName" validate:"required,max=255"`
+	LastName  string     `db:"last_name" json:"lastName" validate:"required,max=255"`
+	Email     string     `db:"email" json:"email" validate:"required,max=255,email"`
+	IsActive  bool       `db:"is_active" json:"isActive"`
+	Created   time.Time  `db:"created" json:"created"`
+	Modified  time.Time  `db:"modified" json:"modified"`
+	DeletedAt *time.Time `db:"deleted_at" json:"deletedAt,omitempty"` // Set for soft deleted users
+}
+
+// UserInput - the fields of a user written by clients on create, replace and patch; read-only fields
+// (uuid, created, modified, deletedAt) are not part of it, so requests sending them are rejected
+type UserInput struct {
+	FirstName string `json:"firstName"`
+	LastName  string `json:"lastName"`
+	Email     string `json:"email"`
+	IsActive  bool   `json:"isActive"`
+}
+
+// newUserInput - returns the writable fields of the user
+func newUserInput(user *User) *UserInput {
+	return &UserInput{
+		FirstName: user.FirstName,
+		LastName:  user.LastName,
+		Email:     user.Email,
+		IsActive:  user.IsActive,
+	}
+}
+
+// applyTo - returns a copy of the user with the writable fields replaced by the input
+func (input *UserInput) applyTo(user *User) *User {
+	updated := *user
+	updated.FirstName = input.FirstName
+	updated.LastName = input.LastName
+	updated.Email = input.Email
+	updated.IsActive = input.IsActive
+
+	return &updated
+}
+
+// updatableColumns - columns which can be changed through the API, in the order they are written
+var updatableColumns = []string{"first_name", "last_name", "email", "is_active"}
+
+// changedColumns - returns the updatable columns whose values differ between the two users
+func changedColumns(current *User, updated *User) map[string]interface{} {
+	changes := make(map[string]interface{})
+	if current.FirstName != updated.FirstName {
+		changes["first_name"] = updated.FirstName
+	}
+	if current.LastName != updated.LastName {
+		changes["last_name"] = updated.LastName
+	}
+	if current.Email != updated.Email {
+		changes["email"] = updated.Email
+	}
+	if current.IsActive != updated.IsActive {
+		changes["is_active"] = updated.IsActive
+	}
+
+	return changes
+}
+
+// ConflictError - returned by the store when a unique field already holds the given value
+type ConflictError struct {
+	Field string
+}
+
+// Error - implements the error interface
+func (e *ConflictError) Error() string {
+	return "user with the same " + e.Field + " already exists"
 }
@@ -1,10 +1,12 @@
 package user
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
+	"mime"
 	"net/http"
-	"strconv"
 
 	"github.com/gorilla/mux"
 
@@ -14,61 +16,123 @@ import (
 // userAPI container - holds dependencies for the user API
 type userAPI struct {
 	handler *api.Handler
-	store   *userStore
+	store   UserStore
+	router  *mux.Router
 }
 
-// AddRoutes - defines routes for the user resource
-func AddRoutes(router *mux.Router, apiHandler *api.Handler) {
+// invalidJSONError - sent when the request body cannot be decoded
+var invalidJSONError = api.NewError(http.StatusBadRequest, api.CodeInvalidJSON, "The request body is not a valid JSON document.")
+
+// AddRoutes - defines routes for the user resource, persisted in the given store
+func AddRoutes(router *mux.Router, apiHandler *api.Handler, store UserStore) {
 	// Initialize userAPI handler
 	uAPI := &userAPI{
 		apiHandler,
-		&userStore{apiHandler.DB},
+		store,
+		router,
 	}
 	router.HandleFunc("/users", uAPI.listUsers).Methods("GET")
 	router.HandleFunc("/users", uAPI.createUser).Methods("POST")
-	router.HandleFunc("/users/{id}", uAPI.getUser).Methods("GET")
+	router.HandleFunc("/users/{id}", uAPI.getUser).Methods("GET").Name("user")
+	router.HandleFunc("/users/{id}", uAPI.replaceUser).Methods("PUT")
+	router.HandleFunc("/users/{id}", uAPI.patchUser).Methods("PATCH")
 	router.HandleFunc("/users/{id}", uAPI.deleteUser).Methods("DELETE")
+	router.HandleFunc("/users/{id}:restore", uAPI.restoreUser).Methods("POST")
+	router.HandleFunc("/users/{id}:purge", apiHandler.RequireAdmin(uAPI.purgeUser)).Methods("POST")
 }
 
 func (uAPI *userAPI) listUsers(w http.ResponseWriter, r *http.Request) {
+	// Filtering and sorting parameters
+	options, apiErr := parseListOptions(r.URL.Query(), uAPI.handler.CursorKey)
+	if apiErr != nil {
+		api.SendError(w, r, apiErr)
+		return
+	}
+
 	// Pagination parameters
-	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
-	offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
-	if limit < 1 || limit > 25 {
-		limit = 10
+	pagination, apiErr := api.ParsePagination(r.URL.Query(), uAPI.handler.PageLimits())
+	if apiErr != nil {
+		api.SendError(w, r, apiErr)
+		return
 	}
-	if offset < 0 {
-		offset = 0
+	limit := pagination.Limit
+	options.Limit = limit
+	options.Offset = pagination.Offset
+	if options.Keyset {
+		// Fetch an extra row, to find out whether a next page exists
+		options.Limit = limit + 1
 	}
+
 	// List users
-	users, err := uAPI.store.List(limit, offset)
+	users, err := uAPI.store.List(r.Context(), options)
+	if err != nil {
+		api.SendError(w, r, storeError(err))
+		return
+	}
+
+	// Count all users matching the filters
+	total, err := uAPI.store.Count(r.Context(), options)
 	if err != nil {
-		api.SendError(w, http.StatusInternalServerError, err.Error())
+		api.SendError(w, r, storeError(err))
 		return
 	}
 
+	page := &api.Page{
+		Items: users,
+		Total: total,
+		Limit: limit,
+	}
+	if !options.Keyset {
+		page.Offset = &options.Offset
+	}
+
+	// Point the client to the next page
+	if options.Keyset && len(users) > limit {
+		page.Items = users[:limit]
+		nextCursor, err := api.EncodeCursor(uAPI.handler.CursorKey, newListCursor(&users[limit-1], options))
+		if err != nil {
+			api.SendError(w, r, api.InternalError(err))
+			return
+		}
+		page.NextCursor = nextCursor
+	}
+
 	// Send the JSON response
-	api.SendJSONResponse(w, http.StatusOK, users)
+	api.SendPage(w, r, http.StatusOK, page)
 }
 
 func (uAPI *userAPI) createUser(w http.ResponseWriter, r *http.Request) {
-	user := &User{}
-	// Try to decode the request body into the user instance
-	err := json.NewDecoder(r.Body).Decode(user)
-	if err != nil {
-		api.SendError(w, http.StatusBadRequest, "")
+	input := &UserInput{}
+	// Try to decode the request body; read-only fields are rejected as unknown
+	if apiErr := api.DecodeJSON(w, r, input); apiErr != nil {
+		api.SendError(w, r, apiErr)
+		return
+	}
+	user := input.applyTo(&User{})
+
+	// Validate the user
+	if apiErr := api.Validate(user); apiErr != nil {
+		api.SendError(w, r, apiErr)
 		return
 	}
 
 	// Create user
-	err = uAPI.store.Create(user)
+	err := uAPI.store.Create(r.Context(), user)
 	if err != nil {
-		api.SendError(w, http.StatusInternalServerError, err.Error())
+		api.SendError(w, r, storeError(err))
 		return
 	}
 
+	// Point the client to the created resource
+	location, err := uAPI.router.Get("user").URL("id", user.UUID.String())
+	if err != nil {
+		api.SendError(w, r, api.InternalError(err))
+		return
+	}
+	w.Header().Set("Location", location.String())
+
 	// Send the JSON response
-	api.SendJSONResponse(w, http.StatusCreated, nil)
+	api.SendJSONResponse(w, http.StatusCreated, user)
 }
 
 func (uAPI *userAPI) getUser(w http.ResponseWriter, r *http.Request) {
@@ -76,16 +140,122 @@ func (uAPI *userAPI) getUser(w http.ResponseWriter, r *http.Request) {
 	params := mux.Vars(r)
 	userID := params["id"]
 
+	// Soft deleted users are only returned on request
+	includeDeleted, apiErr := parseIncludeDeleted(r.URL.Query())
+	if apiErr != nil {
+		api.SendError(w, r, apiErr)
+		return
+	}
+
 	// Get user
-	user, err := uAPI.store.Get(userID)
+	user, err := uAPI.store.Get(r.Context(), userID, includeDeleted)
 	if err != nil {
-		// If the entry does not exist, return 404
-		if err == sql.ErrNoRows {
-			api.SendError(w, http.StatusNotFound, "")
+		api.SendError(w, r, storeError(err))
+		return
+	}
+
+	// Send the JSON response
+	api.SendJSONResponse(w, http.StatusOK, user)
+}
+
+func (uAPI *userAPI) replaceUser(w http.ResponseWriter, r *http.Request) {
+	// Get path parameters
+	params := mux.Vars(r)
+	userID := params["id"]
+
+	input := &UserInput{}
+	// Try to decode the request body; read-only fields are rejected as unknown
+	if apiErr := api.DecodeJSON(w, r, input); apiErr != nil {
+		api.SendError(w, r, apiErr)
+		return
+	}
+
+	// Get the current state of the user
+	current, err := uAPI.store.Get(r.Context(), userID, false)
+	if err != nil {
+		api.SendError(w, r, storeError(err))
+		return
+	}
+
+	uAPI.updateUser(w, r, current, input.applyTo(current))
+}
+
+func (uAPI *userAPI) patchUser(w http.ResponseWriter, r *http.Request) {
+	// Get path parameters
+	params := mux.Vars(r)
+	userID := params["id"]
+
+	// Only JSON Merge Patch documents (or plain JSON) are accepted
+	if contentType := r.Header.Get("Content-Type"); contentType != "" {
+		mediaType, _, err := mime.ParseMediaType(contentType)
+		if err != nil || (mediaType != api.MergePatchContentType && mediaType != "application/json") {
+			api.SendError(w, r, api.NewError(http.StatusUnsupportedMediaType, api.CodeUnsupportedMediaType, "Expected a JSON Merge Patch document."))
 			return
 		}
+	}
 
-		api.SendError(w, http.StatusInternalServerError, err.Error())
+	// Read the patch document
+	patch, apiErr := api.ReadBody(w, r)
+	if apiErr != nil {
+		api.SendError(w, r, apiErr)
+		return
+	}
+
+	// Get the current state of the user
+	current, err := uAPI.store.Get(r.Context(), userID, false)
+	if err != nil {
+		api.SendError(w, r, storeError(err))
+		return
+	}
+
+	// Apply the patch to the JSON representation of the writable fields, so patching read-only fields is rejected
+	document, err := json.Marshal(newUserInput(current))
+	if err != nil {
+		api.SendError(w, r, api.InternalError(err))
+		return
+	}
+	patched, err := api.MergePatch(document, patch)
+	if err != nil {
+		api.SendError(w, r, invalidJSONError)
+		return
+	}
+	input := &UserInput{}
+	if apiErr := api.DecodeJSONBytes(patched, input); apiErr != nil {
+		api.SendError(w, r, apiErr)
+		return
+	}
+
+	uAPI.updateUser(w, r, current, input.applyTo(current))
+}
+
+// updateUser - persists the changes between the current and updated user, then sends the updated user
+func (uAPI *userAPI) updateUser(w http.ResponseWriter, r *http.Request, current *User, updated *User) {
+	userID := current.UUID.String()
+
+	// Validate the updated user
+	if apiErr := api.Validate(updated); apiErr != nil {
+		api.SendError(w, r, apiErr)
+		return
+	}
+
+	// Nothing changed, send the current state
+	changes := changedColumns(current, updated)
+	if len(changes) == 0 {
+		api.SendJSONResponse(w, http.StatusOK, current)
+		return
+	}
+
+	// Update user
+	err := uAPI.store.Update(r.Context(), userID, changes)
+	if err != nil {
+		api.SendError(w, r, storeError(err))
+		return
+	}
+
+	// Fetch the updated user
+	user, err := uAPI.store.Get(r.Context(), userID, false)
+	if err != nil {
+		api.SendError(w, r, storeError(err))
 		return
 	}
 
@@ -99,9 +269,75 @@ func (uAPI *userAPI) deleteUser(w http.ResponseWriter, r *http.Request) {
 	userID := params["id"]
 
 	// Delete user
-	err := uAPI.store.Delete(userID)
+	err := uAPI.store.Delete(r.Context(), userID)
+	if err != nil {
+		api.SendError(w, r, storeError(err))
+		return
+	}
+
+	// Send the JSON response
+	api.SendJSONResponse(w, http.StatusNoContent, nil)
+}
+
+// storeError - maps store errors to API errors
+func storeError(err error) *api.Error {
+	// If the entry does not exist, return 404
+	if err == sql.ErrNoRows {
+		return api.NewError(http.StatusNotFound, api.CodeNotFound, "The user does not exist.")
+	}
+
+	// If the query timed out, return 504
+	if errors.Is(err, context.DeadlineExceeded) {
+		return api.NewError(http.StatusGatewayTimeout, api.CodeTimeout, "The request took too long to complete.")
+	}
+
+	// If the client disconnected, the query was cancelled: not a server failure
+	if errors.Is(err, context.Canceled) {
+		return api.ClientClosedError()
+	}
+
+	// If a unique field is already taken, return 409
+	var conflictErr *ConflictError
+	if errors.As(err, &conflictErr) {
+		return api.NewError(http.StatusConflict, api.CodeConflict, "A user with the same "+conflictErr.Field+" already exists.").
+			WithDetails(api.FieldError{Field: conflictErr.Field, Code: "duplicate", Message: "The value is already in use."})
+	}
+
+	return api.InternalError(err)
+}
+
+func (uAPI *userAPI) restoreUser(w http.ResponseWriter, r *http.Request) {
+	// Get path parameters
+	params := mux.Vars(r)
+	userID := params["id"]
+
+	// Restore user
+	err := uAPI.store.Restore(r.Context(), userID)
+	if err != nil {
+		api.SendError(w, r, storeError(err))
+		return
+	}
+
+	// Fetch the restored user; unknown users are not found
+	user, err := uAPI.store.Get(r.Context(), userID, false)
+	if err != nil {
+		api.SendError(w, r, storeError(err))
+		return
+	}
+
+	// Send the JSON response
+	api.SendJSONResponse(w, http.StatusOK, user)
+}
+
+func (uAPI *userAPI) purgeUser(w http.ResponseWriter, r *http.Request) {
+	// Get path parameters
+	params := mux.Vars(r)
+	userID := params["id"]
+
+	// Permanently delete user
+	err := uAPI.store.Purge(r.Context(), userID)
 	if err != nil {
-		api.SendError(w, http.StatusInternalServerError, err.Error())
+		api.SendError(w, r, storeError(err))
 		return
 	}
 
@@ -2,13 +2,17 @@ package user
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-sql-driver/mysql"
 	"github.com/gorilla/mux"
 	"github.com/jmoiron/sqlx"
 
@@ -20,7 +24,7 @@ func TestAPIAddRoutes(t *testing.T) {
 		router := mux.NewRouter().StrictSlash(true)
 		apiHandler := api.Init(&sqlx.DB{})
 
-		AddRoutes(router, apiHandler)
+		AddRoutes(router, apiHandler, NewSQLStore(apiHandler.DB, api.QueryTimeouts{}))
 		// Iterate over the registered routes
 		exists := false
 		router.Walk(func(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
@@ -51,15 +55,17 @@ func TestAPIListUsers(t *testing.T) {
 			AddRow(1, "1e7aceca-9da3-11ea-bd4c-0242ac140002", "User1FirstName", "User1LastName", "u1fn.u1ln@mail.test", true, time.Now(), time.Now()).
 			AddRow(2, "1e7ad3d8-9da3-11ea-bd4c-0242ac140002", "User2FirstName", "User2LastName", "u2fn.u2ln@mail.test", false, time.Now(), time.Now()).
 			AddRow(3, "1e7ad456-9da3-11ea-bd4c-0242ac140002", "User3FirstName", "User3LastName", "u3fn.u3ln@mail.test", true, time.Now(), time.Now())
-		mock.ExpectQuery("^SELECT id, uuid, first_name, last_name, email, is_active, created, modified FROM user LIMIT \\? OFFSET \\?").
+		mock.ExpectQuery("^SELECT id, uuid, first_name, last_name, email, is_active, created, modified, deleted_at FROM `user` WHERE deleted_at IS NULL ORDER BY created ASC, id ASC LIMIT \\? OFFSET \\?").
 			WithArgs(10, 0).
 			WillReturnRows(rows)
+		mock.ExpectQuery("^SELECT COUNT\\(\\*\\) FROM `user` WHERE deleted_at IS NULL$").
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
 
 		dbHandle := sqlx.NewDb(db, "mysql")
 		// Initialize API and router
 		apiHandler := api.Init(dbHandle)
 		router := mux.NewRouter().StrictSlash(true)
-		AddRoutes(router, apiHandler)
+		AddRoutes(router, apiHandler, NewSQLStore(apiHandler.DB, api.QueryTimeouts{}))
 
 		// Send request
 		req, _ := http.NewRequest("GET", "/users", nil)
@@ -73,18 +79,27 @@ func TestAPIListUsers(t *testing.T) {
 		}
 
 		// Check response body
-		userList := make([]User, 0)
-		err = json.Unmarshal(response.Body.Bytes(), &userList)
+		page := &struct {
+			Items  []User `json:"items"`
+			Total  int64  `json:"total"`
+			Limit  int    `json:"limit"`
+			Offset *int   `json:"offset"`
+		}{}
+		err = json.Unmarshal(response.Body.Bytes(), page)
 		if err != nil {
 			t.Error("Invalid JSON in response body.")
 			return
 		}
 
 		// Check expected response
-		if len(userList) != 3 {
+		if len(page.Items) != 3 || page.Total != 3 || page.Limit != 10 || page.Offset == nil || *page.Offset != 0 {
 			t.Error("Incorrect response body.")
 			return
 		}
+		if response.Header().Get("Link") == "" {
+			t.Error("Link header expected.")
+			return
+		}
 	})
 }
 
@@ -97,17 +112,23 @@ func TestAPICreateUser(t *testing.T) {
 		}
 		defer db.Close()
 
-		mock.ExpectExec("^INSERT INTO user \\(uuid, first_name, last_name, email, is_active, created, modified\\) VALUES \\(UUID\\(\\), \\?, \\?, \\?, \\?, NOW\\(\\), NOW\\(\\)\\)").
-			WithArgs("User1FirstName", "User1LastName", "u1fn.u1ln@mail.test", true).
+		mock.ExpectExec("^INSERT INTO `user` \\(uuid, first_name, last_name, email, is_active, created, modified\\) VALUES \\(\\?, \\?, \\?, \\?, \\?, UTC_TIMESTAMP\\(\\), UTC_TIMESTAMP\\(\\)\\)").
+			WithArgs(sqlmock.AnyArg(), "User1FirstName", "User1LastName", "u1fn.u1ln@mail.test", true).
 			WillReturnResult(sqlmock.NewResult(1, 1))
+		// Created row, read back by UUID
+		rows := sqlmock.NewRows([]string{"id", "uuid", "first_name", "last_name", "email", "is_active", "created", "modified"}).
+			AddRow(1, "1e7aceca-9da3-11ea-bd4c-0242ac140002", "User1FirstName", "User1LastName", "u1fn.u1ln@mail.test", true, time.Now(), time.Now())
+		mock.ExpectQuery("^SELECT id, uuid, first_name, last_name, email, is_active, created, modified, deleted_at FROM `user` WHERE uuid = \\?").
+			WithArgs(sqlmock.AnyArg()).
+			WillReturnRows(rows)
 
 		dbHandle := sqlx.NewDb(db, "mysql")
 		// Initialize API and router
 		apiHandler := api.Init(dbHandle)
 		router := mux.NewRouter().StrictSlash(true)
-		AddRoutes(router, apiHandler)
+		AddRoutes(router, apiHandler, NewSQLStore(apiHandler.DB, api.QueryTimeouts{}))
 
-		jsonUser, _ := json.Marshal(User{FirstName: "User1FirstName", LastName: "User1LastName", Email: "u1fn.u1ln@mail.test", IsActive: true})
+		jsonUser, _ := json.Marshal(UserInput{FirstName: "User1FirstName", LastName: "User1LastName", Email: "u1fn.u1ln@mail.test", IsActive: true})
 		// Send request
 		req, _ := http.NewRequest("POST", "/users", bytes.NewBuffer(jsonUser))
 		response := httptest.NewRecorder()
@@ -118,6 +139,24 @@ func TestAPICreateUser(t *testing.T) {
 			t.Error("Incorrect response code.")
 			return
 		}
+
+		// Check response body
+		user := &User{}
+		err = json.Unmarshal(response.Body.Bytes(), user)
+		if err != nil {
+			t.Error("Invalid JSON in response body.")
+			return
+		}
+		if user.UUID.String() != "1e7aceca-9da3-11ea-bd4c-0242ac140002" {
+			t.Error("Incorrect response body.")
+			return
+		}
+
+		// Check the location of the created resource
+		if response.Header().Get("Location") != "/users/1e7aceca-9da3-11ea-bd4c-0242ac140002" {
+			t.Error("Incorrect Location header.")
+			return
+		}
 	})
 }
 
@@ -133,7 +172,7 @@ func TestAPIGetUser(t *testing.T) {
 		// Add rows to the database
 		rows := sqlmock.NewRows([]string{"id", "uuid", "first_name", "last_name", "email", "is_active", "created", "modified"}).
 			AddRow(1, "1e7aceca-9da3-11ea-bd4c-0242ac140002", "User1FirstName", "User1LastName", "u1fn.u1ln@mail.test", true, time.Now(), time.Now())
-		mock.ExpectQuery("^SELECT id, uuid, first_name, last_name, email, is_active, created, modified FROM user WHERE uuid = \\?").
+		mock.ExpectQuery("^SELECT id, uuid, first_name, last_name, email, is_active, created, modified, deleted_at FROM `user` WHERE uuid = \\?").
 			WithArgs("1e7aceca-9da3-11ea-bd4c-0242ac140002").
 			WillReturnRows(rows)
 
@@ -141,7 +180,7 @@ func TestAPIGetUser(t *testing.T) {
 		// Initialize API and router
 		apiHandler := api.Init(dbHandle)
 		router := mux.NewRouter().StrictSlash(true)
-		AddRoutes(router, apiHandler)
+		AddRoutes(router, apiHandler, NewSQLStore(apiHandler.DB, api.QueryTimeouts{}))
 
 		// Send request
 		req, _ := http.NewRequest("GET", "/users/1e7aceca-9da3-11ea-bd4c-0242ac140002", nil)
@@ -181,7 +220,7 @@ func TestAPIGetNonExistingUser(t *testing.T) {
 
 		// Add rows to the database
 		rows := sqlmock.NewRows([]string{"id", "uuid", "first_name", "last_name", "email", "is_active", "created", "modified"})
-		mock.ExpectQuery("^SELECT id, uuid, first_name, last_name, email, is_active, created, modified FROM user WHERE uuid = \\?").
+		mock.ExpectQuery("^SELECT id, uuid, first_name, last_name, email, is_active, created, modified, deleted_at FROM `user` WHERE uuid = \\?").
 			WithArgs("1e7aceca-9da3-11ea-bd4c-0242ac140002").
 			WillReturnRows(rows)
 
@@ -189,7 +228,7 @@ func TestAPIGetNonExistingUser(t *testing.T) {
 		// Initialize API and router
 		apiHandler := api.Init(dbHandle)
 		router := mux.NewRouter().StrictSlash(true)
-		AddRoutes(router, apiHandler)
+		AddRoutes(router, apiHandler, NewSQLStore(apiHandler.DB, api.QueryTimeouts{}))
 
 		// Send request
 		req, _ := http.NewRequest("GET", "/users/1e7aceca-9da3-11ea-bd4c-0242ac140002", nil)
@@ -201,6 +240,128 @@ func TestAPIGetNonExistingUser(t *testing.T) {
 			t.Error("Incorrect response code.")
 			return
 		}
+
+		// Check response body
+		problem := &api.Error{}
+		err = json.Unmarshal(response.Body.Bytes(), problem)
+		if err != nil || problem.Code != api.CodeNotFound {
+			t.Error("Incorrect response body.")
+			return
+		}
+	})
+}
+
+func TestAPIGetUserTimeout(t *testing.T) {
+	t.Run("API Get user - query timeout", func(t *testing.T) {
+		// Create a mock sql db connection
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Error("Error while opening mock SQL connection.")
+		}
+		defer db.Close()
+
+		// The query outlasts its timeout
+		rows := sqlmock.NewRows([]string{"id", "uuid", "first_name", "last_name", "email", "is_active", "created", "modified"})
+		mock.ExpectQuery("^SELECT id, uuid, first_name, last_name, email, is_active, created, modified, deleted_at FROM `user` WHERE uuid = \\?").
+			WithArgs("1e7aceca-9da3-11ea-bd4c-0242ac140002").
+			WillDelayFor(time.Second).
+			WillReturnRows(rows)
+
+		dbHandle := sqlx.NewDb(db, "mysql")
+		// Initialize API and router
+		apiHandler := api.Init(dbHandle)
+		timeouts := api.QueryTimeouts{Default: time.Second, Operations: map[string]time.Duration{"user.get": 10 * time.Millisecond}}
+		router := mux.NewRouter().StrictSlash(true)
+		AddRoutes(router, apiHandler, NewSQLStore(dbHandle, timeouts))
+
+		// Send request
+		req, _ := http.NewRequest("GET", "/users/1e7aceca-9da3-11ea-bd4c-0242ac140002", nil)
+		response := httptest.NewRecorder()
+		router.ServeHTTP(response, req)
+
+		// Check response code
+		if response.Code != http.StatusGatewayTimeout {
+			t.Error("Incorrect response code:", response.Code)
+			return
+		}
+
+		// Check response body
+		problem := &api.Error{}
+		err = json.Unmarshal(response.Body.Bytes(), problem)
+		if err != nil || problem.Code != api.CodeTimeout {
+			t.Error("Incorrect response body.")
+			return
+		}
+	})
+}
+
+func TestAPIGetUserClientClosed(t *testing.T) {
+	t.Run("API Get user - client disconnected", func(t *testing.T) {
+		// Create a mock sql db connection
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Error("Error while opening mock SQL connection.")
+		}
+		defer db.Close()
+
+		// The client disconnects while the query runs
+		ctx, cancel := context.WithCancel(context.Background())
+		rows := sqlmock.NewRows([]string{"id", "uuid", "first_name", "last_name", "email", "is_active", "created", "modified"})
+		mock.ExpectQuery("^SELECT id, uuid, first_name, last_name, email, is_active, created, modified, deleted_at FROM `user` WHERE uuid = \\?").
+			WithArgs("1e7aceca-9da3-11ea-bd4c-0242ac140002").
+			WillDelayFor(time.Second).
+			WillReturnRows(rows)
+		time.AfterFunc(10*time.Millisecond, cancel)
+
+		dbHandle := sqlx.NewDb(db, "mysql")
+		// Initialize API and router; failures would be logged at error level
+		var output bytes.Buffer
+		apiHandler := api.Init(dbHandle)
+		apiHandler.Logger = slog.New(slog.NewJSONHandler(&output, nil))
+		router := mux.NewRouter().StrictSlash(true)
+		router.Use(apiHandler.AccessLogMiddleware)
+		AddRoutes(router, apiHandler, NewSQLStore(dbHandle, api.QueryTimeouts{}))
+
+		// Send request
+		req, _ := http.NewRequestWithContext(ctx, "GET", "/users/1e7aceca-9da3-11ea-bd4c-0242ac140002", nil)
+		response := httptest.NewRecorder()
+		router.ServeHTTP(response, req)
+
+		// Check response code and logs
+		if response.Code != api.StatusClientClosedRequest {
+			t.Error("Incorrect response code:", response.Code)
+		}
+		if strings.Contains(output.String(), `"level":"ERROR"`) {
+			t.Error("Cancelled requests should not be logged as errors:", output.String())
+		}
+	})
+}
+
+func TestAPICreateUserInvalidJSON(t *testing.T) {
+	t.Run("API Create user - invalid JSON", func(t *testing.T) {
+		// Initialize API and router
+		apiHandler := api.Init(&sqlx.DB{})
+		router := mux.NewRouter().StrictSlash(true)
+		AddRoutes(router, apiHandler, NewSQLStore(apiHandler.DB, api.QueryTimeouts{}))
+
+		// Send request
+		req, _ := http.NewRequest("POST", "/users", bytes.NewBufferString("{"))
+		response := httptest.NewRecorder()
+		router.ServeHTTP(response, req)
+
+		// Check response code
+		if response.Code != 400 || response.Header().Get("Content-Type") != api.ProblemContentType {
+			t.Error("Incorrect response.")
+			return
+		}
+
+		// Check response body
+		problem := &api.Error{}
+		err := json.Unmarshal(response.Body.Bytes(), problem)
+		if err != nil || problem.Code != api.CodeInvalidJSON {
+			t.Error("Incorrect response body.")
+			return
+		}
 	})
 }
 
@@ -213,7 +374,7 @@ func TestAPIDeleteUser(t *testing.T) {
 		}
 		defer db.Close()
 
-		mock.ExpectExec("^DELETE FROM user WHERE uuid = \\?").
+		mock.ExpectExec("^UPDATE `user` SET deleted_at = UTC_TIMESTAMP\\(\\), modified = UTC_TIMESTAMP\\(\\) WHERE uuid = \\? AND deleted_at IS NULL").
 			WithArgs("1e7aceca-9da3-11ea-bd4c-0242ac140002").
 			WillReturnResult(sqlmock.NewResult(1, 1))
 
@@ -221,7 +382,7 @@ func TestAPIDeleteUser(t *testing.T) {
 		// Initialize API and router
 		apiHandler := api.Init(dbHandle)
 		router := mux.NewRouter().StrictSlash(true)
-		AddRoutes(router, apiHandler)
+		AddRoutes(router, apiHandler, NewSQLStore(apiHandler.DB, api.QueryTimeouts{}))
 
 		// Send request
 		req, _ := http.NewRequest("DELETE", "/users/1e7aceca-9da3-11ea-bd4c-0242ac140002", nil)
@@ -235,3 +396,607 @@ func TestAPIDeleteUser(t *testing.T) {
 		}
 	})
 }
+
+func TestAPIReplaceUser(t *testing.T) {
+	t.Run("API Replace user", func(t *testing.T) {
+		// Create a mock sql db connection
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Error("Error while opening mock SQL connection.")
+		}
+		defer db.Close()
+
+		// Current and updated state of the user
+		rows := sqlmock.NewRows([]string{"id", "uuid", "first_name", "last_name", "email", "is_active", "created", "modified"}).
+			AddRow(1, "1e7aceca-9da3-11ea-bd4c-0242ac140002", "User1FirstName", "User1LastName", "u1fn.u1ln@mail.test", true, time.Now(), time.Now())
+		updatedRows := sqlmock.NewRows([]string{"id", "uuid", "first_name", "last_name", "email", "is_active", "created", "modified"}).
+			AddRow(1, "1e7aceca-9da3-11ea-bd4c-0242ac140002", "User1NewFirstName", "User1LastName", "u1fn.u1ln@mail.test", false, time.Now(), time.Now())
+		mock.ExpectQuery("^SELECT id, uuid, first_name, last_name, email, is_active, created, modified, deleted_at FROM `user` WHERE uuid = \\?").
+			WithArgs("1e7aceca-9da3-11ea-bd4c-0242ac140002").
+			WillReturnRows(rows)
+		mock.ExpectExec("^UPDATE `user` SET first_name = \\?, is_active = \\?, modified = UTC_TIMESTAMP\\(\\) WHERE uuid = \\?").
+			WithArgs("User1NewFirstName", false, "1e7aceca-9da3-11ea-bd4c-0242ac140002").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery("^SELECT id, uuid, first_name, last_name, email, is_active, created, modified, deleted_at FROM `user` WHERE uuid = \\?").
+			WithArgs("1e7aceca-9da3-11ea-bd4c-0242ac140002").
+			WillReturnRows(updatedRows)
+
+		dbHandle := sqlx.NewDb(db, "mysql")
+		// Initialize API and router
+		apiHandler := api.Init(dbHandle)
+		router := mux.NewRouter().StrictSlash(true)
+		AddRoutes(router, apiHandler, NewSQLStore(apiHandler.DB, api.QueryTimeouts{}))
+
+		jsonUser, _ := json.Marshal(UserInput{FirstName: "User1NewFirstName", LastName: "User1LastName", Email: "u1fn.u1ln@mail.test", IsActive: false})
+		// Send request
+		req, _ := http.NewRequest("PUT", "/users/1e7aceca-9da3-11ea-bd4c-0242ac140002", bytes.NewBuffer(jsonUser))
+		response := httptest.NewRecorder()
+		router.ServeHTTP(response, req)
+
+		// Check response code
+		if response.Code != 200 {
+			t.Error("Incorrect response code.")
+			return
+		}
+
+		// Check response body
+		user := &User{}
+		err = json.Unmarshal(response.Body.Bytes(), user)
+		if err != nil {
+			t.Error("Invalid JSON in response body.")
+			return
+		}
+		if user.FirstName != "User1NewFirstName" || user.IsActive {
+			t.Error("Incorrect response body.")
+			return
+		}
+	})
+}
+
+func TestAPIPatchUser(t *testing.T) {
+	t.Run("API Patch user", func(t *testing.T) {
+		// Create a mock sql db connection
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Error("Error while opening mock SQL connection.")
+		}
+		defer db.Close()
+
+		// Current and updated state of the user
+		rows := sqlmock.NewRows([]string{"id", "uuid", "first_name", "last_name", "email", "is_active", "created", "modified"}).
+			AddRow(1, "1e7aceca-9da3-11ea-bd4c-0242ac140002", "User1FirstName", "User1LastName", "u1fn.u1ln@mail.test", true, time.Now(), time.Now())
+		updatedRows := sqlmock.NewRows([]string{"id", "uuid", "first_name", "last_name", "email", "is_active", "created", "modified"}).
+			AddRow(1, "1e7aceca-9da3-11ea-bd4c-0242ac140002", "User1FirstName", "User1LastName", "new.u1fn.u1ln@mail.test", true, time.Now(), time.Now())
+		mock.ExpectQuery("^SELECT id, uuid, first_name, last_name, email, is_active, created, modified, deleted_at FROM `user` WHERE uuid = \\?").
+			WithArgs("1e7aceca-9da3-11ea-bd4c-0242ac140002").
+			WillReturnRows(rows)
+		mock.ExpectExec("^UPDATE `user` SET email = \\?, modified = UTC_TIMESTAMP\\(\\) WHERE uuid = \\?").
+			WithArgs("new.u1fn.u1ln@mail.test", "1e7aceca-9da3-11ea-bd4c-0242ac140002").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery("^SELECT id, uuid, first_name, last_name, email, is_active, created, modified, deleted_at FROM `user` WHERE uuid = \\?").
+			WithArgs("1e7aceca-9da3-11ea-bd4c-0242ac140002").
+			WillReturnRows(updatedRows)
+
+		dbHandle := sqlx.NewDb(db, "mysql")
+		// Initialize API and router
+		apiHandler := api.Init(dbHandle)
+		router := mux.NewRouter().StrictSlash(true)
+		AddRoutes(router, apiHandler, NewSQLStore(apiHandler.DB, api.QueryTimeouts{}))
+
+		// Send request
+		req, _ := http.NewRequest("PATCH", "/users/1e7aceca-9da3-11ea-bd4c-0242ac140002", bytes.NewBufferString(`{"email":"new.u1fn.u1ln@mail.test"}`))
+		req.Header.Set("Content-Type", "application/merge-patch+json")
+		response := httptest.NewRecorder()
+		router.ServeHTTP(response, req)
+
+		// Check response code
+		if response.Code != 200 {
+			t.Error("Incorrect response code.")
+			return
+		}
+		if err = mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	})
+}
+
+func TestAPIPatchNonExistingUser(t *testing.T) {
+	t.Run("API Patch non-existing user", func(t *testing.T) {
+		// Create a mock sql db connection
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Error("Error while opening mock SQL connection.")
+		}
+		defer db.Close()
+
+		rows := sqlmock.NewRows([]string{"id", "uuid", "first_name", "last_name", "email", "is_active", "created", "modified"})
+		mock.ExpectQuery("^SELECT id, uuid, first_name, last_name, email, is_active, created, modified, deleted_at FROM `user` WHERE uuid = \\?").
+			WithArgs("1e7aceca-9da3-11ea-bd4c-0242ac140002").
+			WillReturnRows(rows)
+
+		dbHandle := sqlx.NewDb(db, "mysql")
+		// Initialize API and router
+		apiHandler := api.Init(dbHandle)
+		router := mux.NewRouter().StrictSlash(true)
+		AddRoutes(router, apiHandler, NewSQLStore(apiHandler.DB, api.QueryTimeouts{}))
+
+		// Send request
+		req, _ := http.NewRequest("PATCH", "/users/1e7aceca-9da3-11ea-bd4c-0242ac140002", bytes.NewBufferString(`{"isActive":false}`))
+		req.Header.Set("Content-Type", "application/merge-patch+json")
+		response := httptest.NewRecorder()
+		router.ServeHTTP(response, req)
+
+		// Check response code
+		if response.Code != 404 {
+			t.Error("Incorrect response code.")
+			return
+		}
+	})
+}
+
+func TestAPICreateInvalidUser(t *testing.T) {
+	t.Run("API Create user - validation errors", func(t *testing.T) {
+		// Initialize API and router
+		apiHandler := api.Init(&sqlx.DB{})
+		router := mux.NewRouter().StrictSlash(true)
+		AddRoutes(router, apiHandler, NewSQLStore(apiHandler.DB, api.QueryTimeouts{}))
+
+		jsonUser, _ := json.Marshal(UserInput{FirstName: "", LastName: strings.Repeat("a", 256), Email: "u1fn.u1ln", IsActive: true})
+		// Send request
+		req, _ := http.NewRequest("POST", "/users", bytes.NewBuffer(jsonUser))
+		response := httptest.NewRecorder()
+		router.ServeHTTP(response, req)
+
+		// Check response code
+		if response.Code != 422 {
+			t.Error("Incorrect response code.")
+			return
+		}
+
+		// Check every failing field is listed
+		problem := &api.Error{}
+		err := json.Unmarshal(response.Body.Bytes(), problem)
+		if err != nil || problem.Code != api.CodeValidationFailed || len(problem.Errors) != 3 {
+			t.Error("Incorrect response body.")
+			return
+		}
+	})
+}
+
+func TestAPICreateUserUnknownField(t *testing.T) {
+	// Multiple test cases
+	var tests = []struct {
+		name   string
+		method string
+		field  string
+		body   string
+	}{
+		{"API Create user - unknown field", "POST", "isAdmin", `{"firstName":"a","lastName":"b","email":"a.b@mail.test","isAdmin":true}`},
+		{"API Create user - read-only uuid", "POST", "uuid", `{"firstName":"a","lastName":"b","email":"a.b@mail.test","uuid":"not-a-uuid"}`},
+		{"API Create user - read-only created", "POST", "created", `{"firstName":"a","lastName":"b","email":"a.b@mail.test","created":"2020-01-01T00:00:00Z"}`},
+		{"API Replace user - read-only deletedAt", "PUT", "deletedAt", `{"firstName":"a","lastName":"b","email":"a.b@mail.test","deletedAt":null}`},
+		{"API Patch user - read-only modified", "PATCH", "modified", `{"modified":"2020-01-01T00:00:00Z"}`},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// Initialize API and router with an existing user
+			store := NewMemoryStore()
+			user := &User{FirstName: "a", LastName: "b", Email: "a.b@mail.test"}
+			if err := store.Create(context.Background(), user); err != nil {
+				t.Fatal(err)
+			}
+			apiHandler := api.Init(nil)
+			router := mux.NewRouter().StrictSlash(true)
+			AddRoutes(router, apiHandler, store)
+
+			// Send request
+			path := "/users"
+			if test.method != "POST" {
+				path += "/" + user.UUID.String()
+			}
+			req, _ := http.NewRequest(test.method, path, bytes.NewBufferString(test.body))
+			response := httptest.NewRecorder()
+			router.ServeHTTP(response, req)
+
+			// Check the field is rejected as unknown
+			problem := &api.Error{}
+			err := json.Unmarshal(response.Body.Bytes(), problem)
+			if response.Code != 400 || err != nil || problem.Code != api.CodeUnknownField ||
+				len(problem.Errors) != 1 || problem.Errors[0].Field != test.field {
+				t.Error("Incorrect response:", response.Code, response.Body.String())
+			}
+		})
+	}
+}
+
+func TestAPIPatchUserConflict(t *testing.T) {
+	t.Run("API Patch user - email already in use", func(t *testing.T) {
+		// Create a mock sql db connection
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Error("Error while opening mock SQL connection.")
+		}
+		defer db.Close()
+
+		rows := sqlmock.NewRows([]string{"id", "uuid", "first_name", "last_name", "email", "is_active", "created", "modified"}).
+			AddRow(1, "1e7aceca-9da3-11ea-bd4c-0242ac140002", "User1FirstName", "User1LastName", "u1fn.u1ln@mail.test", true, time.Now(), time.Now())
+		mock.ExpectQuery("^SELECT id, uuid, first_name, last_name, email, is_active, created, modified, deleted_at FROM `user` WHERE uuid = \\?").
+			WithArgs("1e7aceca-9da3-11ea-bd4c-0242ac140002").
+			WillReturnRows(rows)
+		mock.ExpectExec("^UPDATE `user` SET email = \\?, modified = UTC_TIMESTAMP\\(\\) WHERE uuid = \\?").
+			WithArgs("u2fn.u2ln@mail.test", "1e7aceca-9da3-11ea-bd4c-0242ac140002").
+			WillReturnError(&mysql.MySQLError{Number: 1062, Message: "Duplicate entry 'u2fn.u2ln@mail.test' for key 'email'"})
+
+		dbHandle := sqlx.NewDb(db, "mysql")
+		// Initialize API and router
+		apiHandler := api.Init(dbHandle)
+		router := mux.NewRouter().StrictSlash(true)
+		AddRoutes(router, apiHandler, NewSQLStore(apiHandler.DB, api.QueryTimeouts{}))
+
+		// Send request
+		req, _ := http.NewRequest("PATCH", "/users/1e7aceca-9da3-11ea-bd4c-0242ac140002", bytes.NewBufferString(`{"email":"u2fn.u2ln@mail.test"}`))
+		req.Header.Set("Content-Type", "application/merge-patch+json")
+		response := httptest.NewRecorder()
+		router.ServeHTTP(response, req)
+
+		// Check response code
+		if response.Code != 409 {
+			t.Error("Incorrect response code.")
+			return
+		}
+
+		// Check the conflicting field is named
+		problem := &api.Error{}
+		err = json.Unmarshal(response.Body.Bytes(), problem)
+		if err != nil || problem.Code != api.CodeConflict || len(problem.Errors) != 1 || problem.Errors[0].Field != "email" {
+			t.Error("Incorrect response body.")
+			return
+		}
+	})
+}
+
+func TestAPIListUsersInvalidParameters(t *testing.T) {
+	// Multiple test cases
+	var tests = []struct {
+		name  string
+		query string
+	}{
+		{"Invalid boolean", "isActive=yes"},
+		{"Invalid timestamp", "createdAfter=yesterday"},
+		{"Sort field not in whitelist", "sort=-password"},
+		{"Limit out of range", "limit=100"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// Initialize API and router
+			apiHandler := api.Init(&sqlx.DB{})
+			router := mux.NewRouter().StrictSlash(true)
+			AddRoutes(router, apiHandler, NewSQLStore(apiHandler.DB, api.QueryTimeouts{}))
+
+			// Send request
+			req, _ := http.NewRequest("GET", "/users?"+test.query, nil)
+			response := httptest.NewRecorder()
+			router.ServeHTTP(response, req)
+
+			// Check response code
+			if response.Code != 400 {
+				t.Error("Incorrect response code.")
+				return
+			}
+		})
+	}
+}
+
+func TestAPIListUsersCursor(t *testing.T) {
+	t.Run("API List users - cursor pagination", func(t *testing.T) {
+		// Create a mock sql db connection
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Error("Error while opening mock SQL connection.")
+		}
+		defer db.Close()
+
+		created := time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC)
+		// First page: one row more than the limit, so a next page exists
+		rows := sqlmock.NewRows([]string{"id", "uuid", "first_name", "last_name", "email", "is_active", "created", "modified"}).
+			AddRow(1, "1e7aceca-9da3-11ea-bd4c-0242ac140002", "User1FirstName", "User1LastName", "u1fn.u1ln@mail.test", true, created, created).
+			AddRow(2, "1e7ad3d8-9da3-11ea-bd4c-0242ac140002", "User2FirstName", "User2LastName", "u2fn.u2ln@mail.test", false, created, created)
+		mock.ExpectQuery("^SELECT id, uuid, first_name, last_name, email, is_active, created, modified, deleted_at FROM `user` WHERE deleted_at IS NULL ORDER BY created ASC, id ASC LIMIT \\? OFFSET \\?").
+			WithArgs(2, 0).
+			WillReturnRows(rows)
+		mock.ExpectQuery("^SELECT COUNT\\(\\*\\) FROM `user` WHERE deleted_at IS NULL$").
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+		// Second page: starts after the last user of the first page
+		nextRows := sqlmock.NewRows([]string{"id", "uuid", "first_name", "last_name", "email", "is_active", "created", "modified"}).
+			AddRow(2, "1e7ad3d8-9da3-11ea-bd4c-0242ac140002", "User2FirstName", "User2LastName", "u2fn.u2ln@mail.test", false, created, created)
+		mock.ExpectQuery("^SELECT id, uuid, first_name, last_name, email, is_active, created, modified, deleted_at FROM `user` "+
+			"WHERE deleted_at IS NULL AND \\(created > \\? OR \\(created = \\? AND id > \\?\\)\\) ORDER BY created ASC, id ASC LIMIT \\? OFFSET \\?").
+			WithArgs(created, created, 1, 2, 0).
+			WillReturnRows(nextRows)
+		mock.ExpectQuery("^SELECT COUNT\\(\\*\\) FROM `user` WHERE deleted_at IS NULL$").
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+
+		dbHandle := sqlx.NewDb(db, "mysql")
+		// Initialize API and router
+		apiHandler := api.Init(dbHandle)
+		router := mux.NewRouter().StrictSlash(true)
+		AddRoutes(router, apiHandler, NewSQLStore(apiHandler.DB, api.QueryTimeouts{}))
+
+		// Send request for the first page
+		req, _ := http.NewRequest("GET", "/users?limit=1&cursor=", nil)
+		response := httptest.NewRecorder()
+		router.ServeHTTP(response, req)
+
+		page := &struct {
+			Items      []User `json:"items"`
+			NextCursor string `json:"nextCursor"`
+		}{}
+		json.Unmarshal(response.Body.Bytes(), page)
+		if response.Code != 200 || len(page.Items) != 1 || page.NextCursor == "" {
+			t.Error("Next cursor expected.")
+			return
+		}
+		if !strings.Contains(response.Header().Get("Link"), `rel="next"`) {
+			t.Error("Link to the next page expected.")
+			return
+		}
+
+		// Send request for the second page
+		req, _ = http.NewRequest("GET", "/users?limit=1&cursor="+page.NextCursor, nil)
+		response = httptest.NewRecorder()
+		router.ServeHTTP(response, req)
+
+		page.NextCursor = ""
+		json.Unmarshal(response.Body.Bytes(), page)
+		if response.Code != 200 || page.NextCursor != "" {
+			t.Error("Last page should have no next cursor.")
+			return
+		}
+		if err = mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	})
+}
+
+func TestAPIListUsersInvalidCursor(t *testing.T) {
+	// Multiple test cases
+	var tests = []struct {
+		name  string
+		query string
+	}{
+		{"Tampered cursor", "cursor=eyJpIjoxfQ.abc"},
+		{"Cursor with offset", "cursor=&offset=10"},
+		{"Cursor with unsupported sort", "cursor=&sort=lastName"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// Initialize API and router
+			apiHandler := api.Init(&sqlx.DB{})
+			router := mux.NewRouter().StrictSlash(true)
+			AddRoutes(router, apiHandler, NewSQLStore(apiHandler.DB, api.QueryTimeouts{}))
+
+			// Send request
+			req, _ := http.NewRequest("GET", "/users?"+test.query, nil)
+			response := httptest.NewRecorder()
+			router.ServeHTTP(response, req)
+
+			// Check response code
+			if response.Code != 400 {
+				t.Error("Incorrect response code.")
+				return
+			}
+		})
+	}
+}
+
+func TestAPIDeleteNonExistingUser(t *testing.T) {
+	t.Run("API Delete non-existing user", func(t *testing.T) {
+		// Create a mock sql db connection
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Error("Error while opening mock SQL connection.")
+		}
+		defer db.Close()
+
+		mock.ExpectExec("^UPDATE `user` SET deleted_at = UTC_TIMESTAMP\\(\\), modified = UTC_TIMESTAMP\\(\\) WHERE uuid = \\? AND deleted_at IS NULL").
+			WithArgs("1e7aceca-9da3-11ea-bd4c-0242ac140002").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		dbHandle := sqlx.NewDb(db, "mysql")
+		// Initialize API and router
+		apiHandler := api.Init(dbHandle)
+		router := mux.NewRouter().StrictSlash(true)
+		AddRoutes(router, apiHandler, NewSQLStore(apiHandler.DB, api.QueryTimeouts{}))
+
+		// Send request
+		req, _ := http.NewRequest("DELETE", "/users/1e7aceca-9da3-11ea-bd4c-0242ac140002", nil)
+		response := httptest.NewRecorder()
+		router.ServeHTTP(response, req)
+
+		// Check response code
+		if response.Code != 404 {
+			t.Error("Incorrect response code.")
+			return
+		}
+	})
+}
+
+func TestAPIGetDeletedUser(t *testing.T) {
+	t.Run("API Get deleted user", func(t *testing.T) {
+		// Create a mock sql db connection
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Error("Error while opening mock SQL connection.")
+		}
+		defer db.Close()
+
+		deletedAt := time.Now()
+		rows := sqlmock.NewRows([]string{"id", "uuid", "first_name", "last_name", "email", "is_active", "created", "modified", "deleted_at"}).
+			AddRow(1, "1e7aceca-9da3-11ea-bd4c-0242ac140002", "User1FirstName", "User1LastName", "u1fn.u1ln@mail.test", true, time.Now(), time.Now(), deletedAt)
+		mock.ExpectQuery("^SELECT id, uuid, first_name, last_name, email, is_active, created, modified, deleted_at FROM `user` WHERE uuid = \\? LIMIT 1").
+			WithArgs("1e7aceca-9da3-11ea-bd4c-0242ac140002").
+			WillReturnRows(rows)
+
+		dbHandle := sqlx.NewDb(db, "mysql")
+		// Initialize API and router
+		apiHandler := api.Init(dbHandle)
+		router := mux.NewRouter().StrictSlash(true)
+		AddRoutes(router, apiHandler, NewSQLStore(apiHandler.DB, api.QueryTimeouts{}))
+
+		// Send request
+		req, _ := http.NewRequest("GET", "/users/1e7aceca-9da3-11ea-bd4c-0242ac140002?includeDeleted=true", nil)
+		response := httptest.NewRecorder()
+		router.ServeHTTP(response, req)
+
+		// Check response code
+		if response.Code != 200 {
+			t.Error("Incorrect response code.")
+			return
+		}
+
+		// Check the deletion time is returned
+		user := &User{}
+		err = json.Unmarshal(response.Body.Bytes(), user)
+		if err != nil || user.DeletedAt == nil {
+			t.Error("Incorrect response body.")
+			return
+		}
+	})
+}
+
+func TestAPIRestoreUser(t *testing.T) {
+	t.Run("API Restore user", func(t *testing.T) {
+		// Create a mock sql db connection
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Error("Error while opening mock SQL connection.")
+		}
+		defer db.Close()
+
+		mock.ExpectExec("^UPDATE `user` SET deleted_at = NULL, modified = UTC_TIMESTAMP\\(\\) WHERE uuid = \\? AND deleted_at IS NOT NULL").
+			WithArgs("1e7aceca-9da3-11ea-bd4c-0242ac140002").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		rows := sqlmock.NewRows([]string{"id", "uuid", "first_name", "last_name", "email", "is_active", "created", "modified"}).
+			AddRow(1, "1e7aceca-9da3-11ea-bd4c-0242ac140002", "User1FirstName", "User1LastName", "u1fn.u1ln@mail.test", true, time.Now(), time.Now())
+		mock.ExpectQuery("^SELECT id, uuid, first_name, last_name, email, is_active, created, modified, deleted_at FROM `user` WHERE uuid = \\? AND deleted_at IS NULL").
+			WithArgs("1e7aceca-9da3-11ea-bd4c-0242ac140002").
+			WillReturnRows(rows)
+
+		dbHandle := sqlx.NewDb(db, "mysql")
+		// Initialize API and router
+		apiHandler := api.Init(dbHandle)
+		router := mux.NewRouter().StrictSlash(true)
+		AddRoutes(router, apiHandler, NewSQLStore(apiHandler.DB, api.QueryTimeouts{}))
+
+		// Send request
+		req, _ := http.NewRequest("POST", "/users/1e7aceca-9da3-11ea-bd4c-0242ac140002:restore", nil)
+		response := httptest.NewRecorder()
+		router.ServeHTTP(response, req)
+
+		// Check response code
+		if response.Code != 200 {
+			t.Error("Incorrect response code.")
+			return
+		}
+		if err = mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	})
+}
+
+func TestAPIPurgeUser(t *testing.T) {
+	// Multiple test cases
+	var tests = []struct {
+		name          string
+		adminToken    string
+		authorization string
+		code          int
+	}{
+		{"Admin operations disabled", "", "Bearer secret", 403},
+		{"Missing token", "secret", "", 401},
+		{"Invalid token", "secret", "Bearer wrong", 403},
+		{"Valid token", "secret", "Bearer secret", 204},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// Create a mock sql db connection
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Error("Error while opening mock SQL connection.")
+			}
+			defer db.Close()
+
+			mock.ExpectExec("^DELETE FROM `user` WHERE uuid = \\?").
+				WithArgs("1e7aceca-9da3-11ea-bd4c-0242ac140002").
+				WillReturnResult(sqlmock.NewResult(0, 1))
+
+			dbHandle := sqlx.NewDb(db, "mysql")
+			// Initialize API and router
+			apiHandler := api.Init(dbHandle)
+			apiHandler.AdminToken = test.adminToken
+			router := mux.NewRouter().StrictSlash(true)
+			AddRoutes(router, apiHandler, NewSQLStore(apiHandler.DB, api.QueryTimeouts{}))
+
+			// Send request
+			req, _ := http.NewRequest("POST", "/users/1e7aceca-9da3-11ea-bd4c-0242ac140002:purge", nil)
+			if test.authorization != "" {
+				req.Header.Set("Authorization", test.authorization)
+			}
+			response := httptest.NewRecorder()
+			router.ServeHTTP(response, req)
+
+			// Check response code
+			if response.Code != test.code {
+				t.Errorf("Expected response code %d, got %d.", test.code, response.Code)
+				return
+			}
+		})
+	}
+}
+
+func TestAPIMemoryStore(t *testing.T) {
+	t.Run("API Create, get and delete user - memory store", func(t *testing.T) {
+		// Initialize API and router without a database
+		apiHandler := api.Init(nil)
+		router := mux.NewRouter().StrictSlash(true)
+		AddRoutes(router, apiHandler, NewMemoryStore())
+
+		jsonUser, _ := json.Marshal(UserInput{FirstName: "User1FirstName", LastName: "User1LastName", Email: "u1fn.u1ln@mail.test", IsActive: true})
+		req, _ := http.NewRequest("POST", "/users", bytes.NewBuffer(jsonUser))
+		response := httptest.NewRecorder()
+		router.ServeHTTP(response, req)
+		if response.Code != 201 {
+			t.Fatal("Incorrect response code on create:", response.Code)
+		}
+		location := response.Header().Get("Location")
+
+		// The same email cannot be used twice
+		req, _ = http.NewRequest("POST", "/users", bytes.NewBuffer(jsonUser))
+		response = httptest.NewRecorder()
+		router.ServeHTTP(response, req)
+		if response.Code != 409 {
+			t.Error("Incorrect response code on duplicate create:", response.Code)
+		}
+
+		req, _ = http.NewRequest("GET", location, nil)
+		response = httptest.NewRecorder()
+		router.ServeHTTP(response, req)
+		if response.Code != 200 {
+			t.Error("Incorrect response code on get:", response.Code)
+		}
+
+		req, _ = http.NewRequest("DELETE", location, nil)
+		response = httptest.NewRecorder()
+		router.ServeHTTP(response, req)
+		if response.Code != 204 {
+			t.Error("Incorrect response code on delete:", response.Code)
+		}
+
+		req, _ = http.NewRequest("GET", location, nil)
+		response = httptest.NewRecorder()
+		router.ServeHTTP(response, req)
+		if response.Code != 404 {
+			t.Error("Incorrect response code on get after delete:", response.Code)
+		}
+	})
+}
@@ -0,0 +1,233 @@
+package user
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"sample-rest-api/app/api"
+	"sample-rest-api/database"
+)
+
+// sortableFields - API fields users can be sorted by, mapped to their columns
+var sortableFields = map[string]string{
+	"firstName": "first_name",
+	"lastName":  "last_name",
+	"email":     "email",
+	"isActive":  "is_active",
+	"created":   "created",
+	"modified":  "modified",
+}
+
+// defaultSort - sort order used when none is requested
+var defaultSort = []api.SortField{{Column: "created"}}
+
+// ListOptions - filtering, sorting and pagination options for listing users
+type ListOptions struct {
+	IsActive      *bool
+	Email         string
+	Search        string
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+	Sort          []api.SortField
+	Limit         int
+	Offset        int
+	// IncludeDeleted - also list soft deleted users
+	IncludeDeleted bool
+	// Keyset - paginate with cursors instead of offsets; only the created sort field is supported
+	Keyset bool
+	// After - position after which the page starts, in keyset mode
+	After *listCursor
+}
+
+// listCursor - position of a user in a keyset paginated list
+type listCursor struct {
+	Created    time.Time `json:"c"`
+	ID         int       `json:"i"`
+	Descending bool      `json:"d"`
+}
+
+// newListCursor - returns the position following the given user
+func newListCursor(user *User, options *ListOptions) *listCursor {
+	return &listCursor{
+		Created:    user.Created,
+		ID:         user.ID,
+		Descending: options.Sort[0].Descending,
+	}
+}
+
+// parseListOptions - reads the list options from the query parameters; cursors are verified with the key
+func parseListOptions(query url.Values, cursorKey []byte) (*ListOptions, *api.Error) {
+	options := &ListOptions{
+		Email:  query.Get("email"),
+		Search: strings.TrimSpace(query.Get("q")),
+	}
+	fieldErrors := make([]api.FieldError, 0)
+
+	includeDeleted, apiErr := parseIncludeDeleted(query)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	options.IncludeDeleted = includeDeleted
+
+	if value := query.Get("isActive"); value != "" {
+		isActive, err := strconv.ParseBool(value)
+		if err != nil {
+			fieldErrors = append(fieldErrors, api.FieldError{Field: "isActive", Code: "invalid_boolean", Message: "The parameter must be true or false."})
+		}
+		options.IsActive = &isActive
+	}
+	timestampParameters := []struct {
+		name   string
+		target **time.Time
+	}{
+		{"createdAfter", &options.CreatedAfter},
+		{"createdBefore", &options.CreatedBefore},
+	}
+	for _, parameter := range timestampParameters {
+		if value := query.Get(parameter.name); value != "" {
+			timestamp, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				fieldErrors = append(fieldErrors, api.FieldError{Field: parameter.name, Code: "invalid_timestamp", Message: "The parameter must be an RFC 3339 timestamp."})
+			}
+			*parameter.target = &timestamp
+		}
+	}
+	if len(fieldErrors) > 0 {
+		return nil, api.NewError(http.StatusBadRequest, api.CodeInvalidParameter, "The query parameters are invalid.").
+			WithDetails(fieldErrors...)
+	}
+
+	sortFields, apiErr := api.ParseSort(query.Get("sort"), sortableFields)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	options.Sort = sortFields
+
+	// Keyset pagination, requested with the cursor parameter (empty for the first page)
+	if _, ok := query["cursor"]; ok {
+		apiErr = parseCursor(options, query, cursorKey)
+		if apiErr != nil {
+			return nil, apiErr
+		}
+	}
+
+	return options, nil
+}
+
+// parseCursor - switches the options to keyset pagination, starting after the given cursor
+func parseCursor(options *ListOptions, query url.Values, cursorKey []byte) *api.Error {
+	if query.Get("offset") != "" {
+		return api.NewError(http.StatusBadRequest, api.CodeInvalidParameter, "The cursor and offset parameters cannot be combined.").
+			WithDetails(api.FieldError{Field: "offset", Code: "unsupported_with_cursor", Message: "Remove the offset parameter when using cursors."})
+	}
+	if len(options.Sort) > 1 || (len(options.Sort) == 1 && options.Sort[0].Column != "created") {
+		return api.NewError(http.StatusBadRequest, api.CodeInvalidParameter, "Cursors only support sorting by created.").
+			WithDetails(api.FieldError{Field: "sort", Code: "unsupported_with_cursor", Message: "Use created or -created when using cursors."})
+	}
+	if len(options.Sort) == 0 {
+		options.Sort = defaultSort
+	}
+	options.Keyset = true
+
+	token := query.Get("cursor")
+	if token == "" {
+		return nil
+	}
+	after := &listCursor{}
+	if apiErr := api.DecodeCursor(cursorKey, token, after); apiErr != nil {
+		return apiErr
+	}
+	// The cursor must have been issued for the same sort direction
+	if after.Descending != options.Sort[0].Descending {
+		return api.NewError(http.StatusBadRequest, api.CodeInvalidCursor, "The cursor was issued for another sort order.").
+			WithDetails(api.FieldError{Field: "cursor", Code: api.CodeInvalidCursor, Message: "Use the same sort parameter as for the previous page."})
+	}
+	options.After = after
+
+	return nil
+}
+
+// where - builds the parameterized WHERE clause for the filters; the email filter and
+// the search ignore the case on every database, like the email unique key
+func (options *ListOptions) where(dialect database.Dialect) (string, []interface{}) {
+	conditions := make([]string, 0)
+	args := make([]interface{}, 0)
+
+	if !options.IncludeDeleted {
+		conditions = append(conditions, "deleted_at IS NULL")
+	}
+
+	if options.IsActive != nil {
+		conditions = append(conditions, "is_active = ?")
+		args = append(args, *options.IsActive)
+	}
+	if options.Email != "" {
+		conditions = append(conditions, dialect.CaseInsensitive("email")+" = "+dialect.CaseInsensitive("?"))
+		args = append(args, options.Email)
+	}
+	if options.Search != "" {
+		prefix := escapeLike(options.Search) + "%"
+		like := " LIKE " + dialect.CaseInsensitive("?") + " ESCAPE '!'"
+		conditions = append(conditions, "("+dialect.CaseInsensitive("first_name")+like+" OR "+
+			dialect.CaseInsensitive("last_name")+like+" OR "+dialect.CaseInsensitive("email")+like+")")
+		args = append(args, prefix, prefix, prefix)
+	}
+	if options.CreatedAfter != nil {
+		conditions = append(conditions, "created > ?")
+		args = append(args, options.CreatedAfter.UTC())
+	}
+	if options.CreatedBefore != nil {
+		conditions = append(conditions, "created < ?")
+		args = append(args, options.CreatedBefore.UTC())
+	}
+	if options.After != nil {
+		operator := ">"
+		if options.After.Descending {
+			operator = "<"
+		}
+		conditions = append(conditions, "(created "+operator+" ? OR (created = ? AND id "+operator+" ?))")
+		args = append(args, options.After.Created.UTC(), options.After.Created.UTC(), options.After.ID)
+	}
+
+	if len(conditions) == 0 {
+		return "", args
+	}
+
+	return " WHERE " + strings.Join(conditions, " AND "), args
+}
+
+// orderBy - builds the ORDER BY clause; the primary key is always the last sort column
+// (in the direction of the previous one), so the order is stable across pages
+func (options *ListOptions) orderBy() string {
+	sortFields := options.Sort
+	if len(sortFields) == 0 {
+		sortFields = defaultSort
+	}
+	tieBreaker := api.SortField{Column: "id", Descending: sortFields[len(sortFields)-1].Descending}
+
+	return api.OrderBy(append(sortFields[:len(sortFields):len(sortFields)], tieBreaker))
+}
+
+// escapeLike - escapes the LIKE wildcards in the value with !, declared with ESCAPE as SQLite has no default escape character
+func escapeLike(value string) string {
+	return strings.NewReplacer(`!`, `!!`, `%`, `!%`, `_`, `!_`).Replace(value)
+}
+
+// parseIncludeDeleted - reads the includeDeleted query parameter
+func parseIncludeDeleted(query url.Values) (bool, *api.Error) {
+	value := query.Get("includeDeleted")
+	if value == "" {
+		return false, nil
+	}
+
+	includeDeleted, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, api.NewError(http.StatusBadRequest, api.CodeInvalidParameter, "The query parameters are invalid.").
+			WithDetails(api.FieldError{Field: "includeDeleted", Code: "invalid_boolean", Message: "The parameter must be true or false."})
+	}
+
+	return includeDeleted, nil
+}
@@ -1,66 +1,323 @@
 package user
 
 import (
-	"log"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
 
 	"github.com/jmoiron/sqlx"
+	uuid "github.com/satori/go.uuid"
+
+	"sample-rest-api/app/api"
+	"sample-rest-api/app/logging"
+	"sample-rest-api/app/metrics"
+	"sample-rest-api/app/tracing"
+	"sample-rest-api/database"
 )
 
-type userStore struct {
+// uniqueKeyFields - JSON fields guarded by the unique keys of the user table,
+// by key name (MySQL, SQLite) or constraint name (PostgreSQL)
+var uniqueKeyFields = map[string]string{
+	"uuid":           "uuid",
+	"email":          "email",
+	"user_uuid_key":  "uuid",
+	"user_email_key": "email",
+}
+
+// userColumns - columns selected when reading users
+const userColumns = `id, uuid, first_name, last_name, email, is_active, created, modified, deleted_at`
+
+// UserStore - persistence of users. Methods return sql.ErrNoRows for unknown users
+// and a *ConflictError when a unique field is already taken.
+type UserStore interface {
+	// List - returns the users matching the options, sorted and paginated
+	List(ctx context.Context, options *ListOptions) ([]User, error)
+	// Count - returns the number of users matching the filters of the options, ignoring the pagination
+	Count(ctx context.Context, options *ListOptions) (int64, error)
+	// Create - stores a new user; the user is populated with the stored values
+	Create(ctx context.Context, user *User) error
+	// Get - returns a user; soft deleted users are only returned if includeDeleted is set
+	Get(ctx context.Context, userID string, includeDeleted bool) (*User, error)
+	// Update - changes the given columns of a user which is not soft deleted
+	Update(ctx context.Context, userID string, changes map[string]interface{}) error
+	// Delete - soft deletes a user
+	Delete(ctx context.Context, userID string) error
+	// Restore - restores a soft deleted user; restoring a user which is not deleted has no effect
+	Restore(ctx context.Context, userID string) error
+	// Purge - permanently deletes a user, soft deleted or not
+	Purge(ctx context.Context, userID string) error
+}
+
+// SQLStore - UserStore backed by the user table, in any database with a dialect
+type SQLStore struct {
 	DB *sqlx.DB
+	// Timeouts - time allowed for the store methods, by operation, e.g. "user.list"
+	Timeouts api.QueryTimeouts
+}
+
+// NewSQLStore - creates a user store on the database handle
+func NewSQLStore(db *sqlx.DB, timeouts api.QueryTimeouts) *SQLStore {
+	return &SQLStore{DB: db, Timeouts: timeouts}
 }
 
 // List - store method for listing users
-func (ss *userStore) List(limit int, offset int) ([]User, error) {
+func (ss *SQLStore) List(ctx context.Context, options *ListOptions) ([]User, error) {
+	defer metrics.ObserveQuery("user", "List", time.Now())
+	ctx, cancel := ss.Timeouts.WithTimeout(ctx, "user.list")
+	defer cancel()
+
 	users := make([]User, 0)
-	userQuery := `SELECT id, uuid, first_name, last_name, email, is_active, created, modified FROM user LIMIT ? OFFSET ?`
+	where, args := options.where(ss.dialect())
+	userQuery := `SELECT ` + userColumns + ` FROM ` + ss.table() +
+		where + ` ORDER BY ` + options.orderBy() + ` LIMIT ? OFFSET ?`
+	args = append(args, options.Limit, options.Offset)
 	// Execute the query while preventing SQL injection
-	err := ss.DB.Select(&users, userQuery, limit, offset)
+	err := ss.selectTraced(ctx, "List", &users, userQuery, args...)
 	if err != nil {
-		log.Println(err)
+		logError(ctx, "List", err)
 		return nil, err
 	}
 
 	return users, nil
 }
 
-// Create - store method for creating a user
-func (ss *userStore) Create(user *User) error {
-	userQuery := `INSERT INTO user (uuid, first_name, last_name, email, is_active, created, modified) 
-				VALUES (UUID(), ?, ?, ?, ?, NOW(), NOW())`
+// Count - store method for counting the users matching the list filters
+func (ss *SQLStore) Count(ctx context.Context, options *ListOptions) (int64, error) {
+	defer metrics.ObserveQuery("user", "Count", time.Now())
+	ctx, cancel := ss.Timeouts.WithTimeout(ctx, "user.count")
+	defer cancel()
+
+	var total int64
+	// The cursor position is not a filter, so it is not counted against
+	countOptions := *options
+	countOptions.After = nil
+	where, args := countOptions.where(ss.dialect())
+	userQuery := `SELECT COUNT(*) FROM ` + ss.table() + where
 	// Execute the query while preventing SQL injection
-	_, err := ss.DB.Exec(userQuery, user.FirstName, user.LastName, user.Email, user.IsActive)
+	err := ss.getTraced(ctx, "Count", &total, userQuery, args...)
+	if err != nil {
+		logError(ctx, "Count", err)
+		return 0, err
+	}
+
+	return total, nil
+}
+
+// Create - store method for creating a user; the user is populated with the stored values
+func (ss *SQLStore) Create(ctx context.Context, user *User) error {
+	defer metrics.ObserveQuery("user", "Create", time.Now())
+	ctx, cancel := ss.Timeouts.WithTimeout(ctx, "user.create")
+	defer cancel()
+
+	// Generate the UUID here, so the created row can be read back
+	user.UUID = uuid.NewV4()
+
+	now := ss.dialect().Now()
+	userQuery := `INSERT INTO ` + ss.table() + ` (uuid, first_name, last_name, email, is_active, created, modified) 
+				VALUES (?, ?, ?, ?, ?, ` + now + `, ` + now + `)`
+	// Execute the query while preventing SQL injection
+	_, err := ss.execTraced(ctx, "Create", userQuery, user.UUID.String(), user.FirstName, user.LastName, user.Email, user.IsActive)
+	if err != nil {
+		err = ss.conflictError(err)
+		logError(ctx, "Create", err)
+		return err
+	}
+
+	// Read back the created row, to get the generated ID and timestamps
+	userQuery = `SELECT ` + userColumns + ` FROM ` + ss.table() + ` WHERE uuid = ? LIMIT 1`
+	err = ss.getTraced(ctx, "Create", user, userQuery, user.UUID.String())
 	if err != nil {
-		log.Println(err)
+		logError(ctx, "Create", err)
 		return err
 	}
 
 	return nil
 }
 
-// Get - store method for fetching a user
-func (ss *userStore) Get(userID string) (*User, error) {
+// Get - store method for fetching a user; soft deleted users are only returned if includeDeleted is set
+func (ss *SQLStore) Get(ctx context.Context, userID string, includeDeleted bool) (*User, error) {
+	defer metrics.ObserveQuery("user", "Get", time.Now())
+	ctx, cancel := ss.Timeouts.WithTimeout(ctx, "user.get")
+	defer cancel()
+
 	user := &User{}
-	userQuery := `SELECT id, uuid, first_name, last_name, email, is_active, created, modified FROM user WHERE uuid = ? LIMIT 1`
+	userQuery := `SELECT ` + userColumns + ` FROM ` + ss.table() + ` WHERE uuid = ? AND deleted_at IS NULL LIMIT 1`
+	if includeDeleted {
+		userQuery = `SELECT ` + userColumns + ` FROM ` + ss.table() + ` WHERE uuid = ? LIMIT 1`
+	}
 	// Execute the query while preventing SQL injection
-	err := ss.DB.Get(user, userQuery, userID)
+	err := ss.getTraced(ctx, "Get", user, userQuery, userID)
 	if err != nil {
-		log.Println(err)
+		logError(ctx, "Get", err)
 		return nil, err
 	}
 
 	return user, nil
 }
 
-// Delete - store method for deleting a user
-func (ss *userStore) Delete(userID string) error {
-	userQuery := `DELETE FROM user WHERE uuid = ?`
+// Delete - store method for soft deleting a user; returns sql.ErrNoRows for unknown or already deleted users
+func (ss *SQLStore) Delete(ctx context.Context, userID string) error {
+	defer metrics.ObserveQuery("user", "Delete", time.Now())
+	ctx, cancel := ss.Timeouts.WithTimeout(ctx, "user.delete")
+	defer cancel()
+
+	now := ss.dialect().Now()
+	userQuery := `UPDATE ` + ss.table() + ` SET deleted_at = ` + now + `, modified = ` + now + ` WHERE uuid = ? AND deleted_at IS NULL`
+	// Execute the query while preventing SQL injection
+	return ss.execOne(ctx, "Delete", userQuery, userID)
+}
+
+// Restore - store method for restoring a soft deleted user; restoring a user which is not deleted has no effect
+func (ss *SQLStore) Restore(ctx context.Context, userID string) error {
+	defer metrics.ObserveQuery("user", "Restore", time.Now())
+	ctx, cancel := ss.Timeouts.WithTimeout(ctx, "user.restore")
+	defer cancel()
+
+	userQuery := `UPDATE ` + ss.table() + ` SET deleted_at = NULL, modified = ` + ss.dialect().Now() + ` WHERE uuid = ? AND deleted_at IS NOT NULL`
 	// Execute the query while preventing SQL injection
-	_, err := ss.DB.Exec(userQuery, userID)
+	err := ss.execOne(ctx, "Restore", userQuery, userID)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+
+	return err
+}
+
+// Purge - store method for permanently deleting a user, soft deleted or not
+func (ss *SQLStore) Purge(ctx context.Context, userID string) error {
+	defer metrics.ObserveQuery("user", "Purge", time.Now())
+	ctx, cancel := ss.Timeouts.WithTimeout(ctx, "user.purge")
+	defer cancel()
+
+	userQuery := `DELETE FROM ` + ss.table() + ` WHERE uuid = ?`
+	// Execute the query while preventing SQL injection
+	return ss.execOne(ctx, "Purge", userQuery, userID)
+}
+
+// Update - store method for updating the given columns of a user
+func (ss *SQLStore) Update(ctx context.Context, userID string, changes map[string]interface{}) error {
+	defer metrics.ObserveQuery("user", "Update", time.Now())
+	ctx, cancel := ss.Timeouts.WithTimeout(ctx, "user.update")
+	defer cancel()
+
+	// Build the SET clause from the whitelisted columns only, in a stable order
+	setClauses := make([]string, 0, len(updatableColumns)+1)
+	args := make([]interface{}, 0, len(updatableColumns)+1)
+	for _, column := range updatableColumns {
+		value, ok := changes[column]
+		if !ok {
+			continue
+		}
+		setClauses = append(setClauses, column+" = ?")
+		args = append(args, value)
+	}
+	setClauses = append(setClauses, "modified = "+ss.dialect().Now())
+	args = append(args, userID)
+
+	userQuery := `UPDATE ` + ss.table() + ` SET ` + strings.Join(setClauses, ", ") + ` WHERE uuid = ? AND deleted_at IS NULL`
+	// Execute the query while preventing SQL injection
+	return ss.execOne(ctx, "Update", userQuery, args...)
+}
+
+// execOne - executes a statement targeting a single user; returns sql.ErrNoRows if no row was affected
+func (ss *SQLStore) execOne(ctx context.Context, method string, query string, args ...interface{}) error {
+	result, err := ss.execTraced(ctx, method, query, args...)
 	if err != nil {
-		log.Println(err)
+		err = ss.conflictError(err)
+		logError(ctx, method, err)
 		return err
 	}
 
+	// No affected rows means the user does not exist
+	affected, err := result.RowsAffected()
+	if err != nil {
+		logError(ctx, method, err)
+		return err
+	}
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+
 	return nil
 }
+
+// dialect - SQL dialect of the database
+func (ss *SQLStore) dialect() database.Dialect {
+	return database.DialectOf(ss.DB)
+}
+
+// table - quoted name of the user table
+func (ss *SQLStore) table() string {
+	return ss.dialect().Quote("user")
+}
+
+// selectTraced - runs a query returning rows within a span named after the store method;
+// the ? placeholders of the query are converted for the driver, as in the other traced methods
+func (ss *SQLStore) selectTraced(ctx context.Context, method string, dest interface{}, query string, args ...interface{}) error {
+	query = ss.DB.Rebind(query)
+	ctx, span := tracing.StartQuery(ctx, ss.dialect().Name(), "userStore."+method, query)
+	err := contextError(ctx, ss.DB.SelectContext(ctx, dest, query, args...))
+	tracing.EndQuery(span, err)
+
+	return err
+}
+
+// getTraced - runs a query returning a single row within a span named after the store method
+func (ss *SQLStore) getTraced(ctx context.Context, method string, dest interface{}, query string, args ...interface{}) error {
+	query = ss.DB.Rebind(query)
+	ctx, span := tracing.StartQuery(ctx, ss.dialect().Name(), "userStore."+method, query)
+	err := contextError(ctx, ss.DB.GetContext(ctx, dest, query, args...))
+	tracing.EndQuery(span, err)
+
+	return err
+}
+
+// execTraced - runs a statement within a span named after the store method
+func (ss *SQLStore) execTraced(ctx context.Context, method string, query string, args ...interface{}) (sql.Result, error) {
+	query = ss.DB.Rebind(query)
+	ctx, span := tracing.StartQuery(ctx, ss.dialect().Name(), "userStore."+method, query)
+	result, err := ss.DB.ExecContext(ctx, query, args...)
+	err = contextError(ctx, err)
+	tracing.EndQuery(span, err)
+
+	return result, err
+}
+
+// contextError - drivers report cancelled queries differently; if the context is done,
+// the returned error wraps its error (context.DeadlineExceeded or context.Canceled)
+func contextError(ctx context.Context, err error) error {
+	if err == nil || ctx.Err() == nil || errors.Is(err, ctx.Err()) {
+		return err
+	}
+
+	return fmt.Errorf("%w: %v", ctx.Err(), err)
+}
+
+// logError - logs a failed store query with the request scoped logger;
+// missing users, conflicts and requests cancelled by the client are expected outcomes, logged at debug level
+func logError(ctx context.Context, method string, err error) {
+	level := slog.LevelError
+	var conflictErr *ConflictError
+	if errors.Is(err, sql.ErrNoRows) || errors.As(err, &conflictErr) || errors.Is(err, context.Canceled) {
+		level = slog.LevelDebug
+	}
+	logging.FromContext(ctx).Log(ctx, level, "User store query failed", "method", method, "error", err)
+}
+
+// conflictError - converts unique constraint violations into a ConflictError; other errors, and violations
+// of keys which cannot be identified, are returned as they are
+func (ss *SQLStore) conflictError(err error) error {
+	key, ok := ss.dialect().DuplicateKey(err)
+	if !ok || key == "" {
+		return err
+	}
+	if field, ok := uniqueKeyFields[key]; ok {
+		return &ConflictError{Field: field}
+	}
+
+	return &ConflictError{Field: key}
+}
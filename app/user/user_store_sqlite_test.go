@@ -0,0 +1,147 @@
+package user
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"sample-rest-api/app/api"
+	"sample-rest-api/database"
+	"sample-rest-api/database/migrate"
+)
+
+// sqliteStore - returns a user store on a migrated SQLite database, removed after the test
+func sqliteStore(t *testing.T) *SQLStore {
+	dbHandle := database.Connect(context.Background(), &database.Options{
+		Driver: database.DriverSQLite,
+		Name:   filepath.Join(t.TempDir(), "test.db"),
+	})
+	if dbHandle == nil {
+		t.Fatal("Could not open SQLite database.")
+	}
+	t.Cleanup(func() { dbHandle.Close() })
+
+	migrator, err := migrate.New(dbHandle)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrator.Up(); err != nil {
+		t.Fatal("Could not apply migrations:", err)
+	}
+
+	return NewSQLStore(dbHandle, api.QueryTimeouts{})
+}
+
+func TestSQLiteStore(t *testing.T) {
+	t.Run("Create, list and get users - SQLite", func(t *testing.T) {
+		store := sqliteStore(t)
+		ctx := context.Background()
+
+		users := []User{
+			{FirstName: "Alice", LastName: "Smith", Email: "alice@mail.test", IsActive: true},
+			{FirstName: "Bob", LastName: "Jo_nes", Email: "bob@mail.test"},
+			{FirstName: "Carol", LastName: "Allen", Email: "carol@mail.test", IsActive: true},
+		}
+		for i := range users {
+			if err := store.Create(ctx, &users[i]); err != nil {
+				t.Fatal("Could not create user:", err)
+			}
+		}
+		if users[0].ID == 0 || users[0].Created.IsZero() {
+			t.Error("Created user should be read back.")
+		}
+
+		found, err := store.Get(ctx, users[1].UUID.String(), false)
+		if err != nil || found.Email != "bob@mail.test" || found.IsActive {
+			t.Error("Incorrect user:", found, err)
+		}
+
+		active := true
+		listed, err := store.List(ctx, &ListOptions{Limit: 10, IsActive: &active, Sort: []api.SortField{{Column: "last_name"}}})
+		if err != nil || len(listed) != 2 || listed[0].Email != "carol@mail.test" {
+			t.Error("Incorrect filtered list:", listed, err)
+		}
+
+		// The underscore is not a wildcard
+		listed, err = store.List(ctx, &ListOptions{Limit: 10, Search: "jo_"})
+		if err != nil || len(listed) != 1 {
+			t.Error("Incorrect search:", listed, err)
+		}
+		listed, _ = store.List(ctx, &ListOptions{Limit: 10, Search: "jox"})
+		if len(listed) != 0 {
+			t.Error("LIKE wildcards should be escaped:", listed)
+		}
+
+		// Keyset pagination
+		options := &ListOptions{Limit: 10, Sort: defaultSort, Keyset: true}
+		options.After = newListCursor(&users[0], options)
+		listed, err = store.List(ctx, options)
+		if err != nil || len(listed) != 2 || listed[0].Email != "bob@mail.test" {
+			t.Error("Incorrect page after the cursor:", listed, err)
+		}
+		count, err := store.Count(ctx, options)
+		if err != nil || count != 3 {
+			t.Error("Incorrect count:", count, err)
+		}
+	})
+
+	t.Run("Duplicate email - SQLite", func(t *testing.T) {
+		store := sqliteStore(t)
+		ctx := context.Background()
+
+		if err := store.Create(ctx, &User{FirstName: "A", LastName: "B", Email: "a@mail.test"}); err != nil {
+			t.Fatal("Could not create user:", err)
+		}
+		err := store.Create(ctx, &User{FirstName: "A", LastName: "B", Email: "A@mail.test"})
+		var conflictErr *ConflictError
+		if !errors.As(err, &conflictErr) || conflictErr.Field != "email" {
+			t.Error("Expected an email conflict, got:", err)
+		}
+	})
+
+	t.Run("Update, delete, restore and purge user - SQLite", func(t *testing.T) {
+		store := sqliteStore(t)
+		ctx := context.Background()
+
+		user := &User{FirstName: "A", LastName: "B", Email: "a@mail.test"}
+		if err := store.Create(ctx, user); err != nil {
+			t.Fatal("Could not create user:", err)
+		}
+		userID := user.UUID.String()
+
+		if err := store.Update(ctx, userID, map[string]interface{}{"first_name": "Changed", "is_active": true}); err != nil {
+			t.Fatal("Unexpected error:", err)
+		}
+		found, _ := store.Get(ctx, userID, false)
+		if found.FirstName != "Changed" || !found.IsActive {
+			t.Error("Incorrect update:", found)
+		}
+
+		if err := store.Delete(ctx, userID); err != nil {
+			t.Fatal("Unexpected error:", err)
+		}
+		found, err := store.Get(ctx, userID, true)
+		if err != nil || found.DeletedAt == nil {
+			t.Error("Deleted user should be marked deleted:", found, err)
+		}
+		if err := store.Delete(ctx, userID); err != sql.ErrNoRows {
+			t.Error("Deleting a deleted user should fail, got:", err)
+		}
+
+		if err := store.Restore(ctx, userID); err != nil {
+			t.Fatal("Unexpected error:", err)
+		}
+		if _, err := store.Get(ctx, userID, false); err != nil {
+			t.Error("Restored user should be found.")
+		}
+
+		if err := store.Purge(ctx, userID); err != nil {
+			t.Fatal("Unexpected error:", err)
+		}
+		if err := store.Purge(ctx, userID); err != sql.ErrNoRows {
+			t.Error("Purging an unknown user should fail, got:", err)
+		}
+	})
+}
@@ -1,11 +1,16 @@
 package user
 
 import (
+	"context"
+	"database/sql"
 	"testing"
 	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-sql-driver/mysql"
 	"github.com/jmoiron/sqlx"
+
+	"sample-rest-api/app/api"
 )
 
 func TestStoreList(t *testing.T) {
@@ -24,14 +29,14 @@ func TestStoreList(t *testing.T) {
 			AddRow(2, "1e7ad3d8-9da3-11ea-bd4c-0242ac140002", "User2FirstName", "User2LastName", "u2fn.u2ln@mail.test", false, time.Now(), time.Now()).
 			AddRow(3, "1e7ad456-9da3-11ea-bd4c-0242ac140002", "User3FirstName", "User3LastName", "u3fn.u3ln@mail.test", true, time.Now(), time.Now())
 
-		mock.ExpectQuery("^SELECT id, uuid, first_name, last_name, email, is_active, created, modified FROM user LIMIT \\? OFFSET \\?").
+		mock.ExpectQuery("^SELECT id, uuid, first_name, last_name, email, is_active, created, modified, deleted_at FROM `user` WHERE deleted_at IS NULL ORDER BY created ASC, id ASC LIMIT \\? OFFSET \\?").
 			WithArgs(3, 1).
 			WillReturnRows(rows)
 
 		dbHandle := sqlx.NewDb(db, "mysql")
 		// Initialize user store
-		userStore := &userStore{dbHandle}
-		userList, err := userStore.List(3, 1)
+		userStore := &SQLStore{DB: dbHandle}
+		userList, err := userStore.List(context.Background(), &ListOptions{Limit: 3, Offset: 1})
 		if err != nil {
 			t.Error("Unexpected error.")
 		}
@@ -43,6 +48,154 @@ func TestStoreList(t *testing.T) {
 	})
 }
 
+func TestStoreListFiltered(t *testing.T) {
+	t.Run("List users - filtered and sorted", func(t *testing.T) {
+
+		// Create a mock sql db connection
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Error("Error while opening mock SQL connection.")
+		}
+		defer db.Close()
+
+		rows := sqlmock.NewRows([]string{"id", "uuid", "first_name", "last_name", "email", "is_active", "created", "modified"}).
+			AddRow(1, "1e7aceca-9da3-11ea-bd4c-0242ac140002", "User1FirstName", "User1LastName", "u1fn.u1ln@mail.test", true, time.Now(), time.Now())
+
+		createdAfter := time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC)
+		mock.ExpectQuery("^SELECT id, uuid, first_name, last_name, email, is_active, created, modified, deleted_at FROM `user` "+
+			"WHERE deleted_at IS NULL AND is_active = \\? AND \\(first_name LIKE \\? ESCAPE '!' OR last_name LIKE \\? ESCAPE '!' OR email LIKE \\? ESCAPE '!'\\) AND created > \\? "+
+			"ORDER BY created DESC, last_name ASC, id ASC LIMIT \\? OFFSET \\?").
+			WithArgs(true, "u!_1%", "u!_1%", "u!_1%", createdAfter, 10, 0).
+			WillReturnRows(rows)
+
+		dbHandle := sqlx.NewDb(db, "mysql")
+		// Initialize user store
+		userStore := &SQLStore{DB: dbHandle}
+		isActive := true
+		options := &ListOptions{
+			IsActive:     &isActive,
+			Search:       "u_1",
+			CreatedAfter: &createdAfter,
+			Sort:         []api.SortField{{Column: "created", Descending: true}, {Column: "last_name"}},
+			Limit:        10,
+		}
+		userList, err := userStore.List(context.Background(), options)
+		if err != nil {
+			t.Error("Unexpected error.")
+		}
+
+		// Check user count
+		if len(userList) != 1 {
+			t.Error("User count should be 1")
+		}
+	})
+}
+
+func TestStoreListCaseInsensitivePostgres(t *testing.T) {
+	t.Run("List users - email filter and search ignore the case on PostgreSQL", func(t *testing.T) {
+
+		// Create a mock sql db connection
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Error("Error while opening mock SQL connection.")
+		}
+		defer db.Close()
+
+		rows := sqlmock.NewRows([]string{"id", "uuid", "first_name", "last_name", "email", "is_active", "created", "modified"}).
+			AddRow(1, "1e7aceca-9da3-11ea-bd4c-0242ac140002", "User1FirstName", "User1LastName", "u1fn.u1ln@mail.test", true, time.Now(), time.Now())
+
+		mock.ExpectQuery("^SELECT id, uuid, first_name, last_name, email, is_active, created, modified, deleted_at FROM \"user\" "+
+			"WHERE deleted_at IS NULL AND lower\\(email\\) = lower\\(\\$1\\) AND \\(lower\\(first_name\\) LIKE lower\\(\\$2\\) ESCAPE '!' "+
+			"OR lower\\(last_name\\) LIKE lower\\(\\$3\\) ESCAPE '!' OR lower\\(email\\) LIKE lower\\(\\$4\\) ESCAPE '!'\\) "+
+			"ORDER BY created ASC, id ASC LIMIT \\$5 OFFSET \\$6").
+			WithArgs("U1FN.U1LN@mail.test", "User1%", "User1%", "User1%", 10, 0).
+			WillReturnRows(rows)
+
+		dbHandle := sqlx.NewDb(db, "pgx")
+		// Initialize user store
+		userStore := &SQLStore{DB: dbHandle}
+		userList, err := userStore.List(context.Background(), &ListOptions{Email: "U1FN.U1LN@mail.test", Search: "User1", Limit: 10})
+		if err != nil {
+			t.Error("Unexpected error:", err)
+		}
+
+		// Check user count
+		if len(userList) != 1 {
+			t.Error("User count should be 1")
+		}
+	})
+}
+
+func TestStoreListKeyset(t *testing.T) {
+	t.Run("List users - after cursor, descending", func(t *testing.T) {
+
+		// Create a mock sql db connection
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Error("Error while opening mock SQL connection.")
+		}
+		defer db.Close()
+
+		rows := sqlmock.NewRows([]string{"id", "uuid", "first_name", "last_name", "email", "is_active", "created", "modified"}).
+			AddRow(1, "1e7aceca-9da3-11ea-bd4c-0242ac140002", "User1FirstName", "User1LastName", "u1fn.u1ln@mail.test", true, time.Now(), time.Now())
+
+		created := time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC)
+		mock.ExpectQuery("^SELECT id, uuid, first_name, last_name, email, is_active, created, modified, deleted_at FROM `user` "+
+			"WHERE deleted_at IS NULL AND \\(created < \\? OR \\(created = \\? AND id < \\?\\)\\) "+
+			"ORDER BY created DESC, id DESC LIMIT \\? OFFSET \\?").
+			WithArgs(created, created, 7, 11, 0).
+			WillReturnRows(rows)
+
+		dbHandle := sqlx.NewDb(db, "mysql")
+		// Initialize user store
+		userStore := &SQLStore{DB: dbHandle}
+		options := &ListOptions{
+			Sort:   []api.SortField{{Column: "created", Descending: true}},
+			Limit:  11,
+			Keyset: true,
+			After:  &listCursor{Created: created, ID: 7, Descending: true},
+		}
+		_, err = userStore.List(context.Background(), options)
+		if err != nil {
+			t.Error("Unexpected error.")
+		}
+	})
+}
+
+func TestStoreCount(t *testing.T) {
+	t.Run("Count users - filtered, ignoring the cursor", func(t *testing.T) {
+
+		// Create a mock sql db connection
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Error("Error while opening mock SQL connection.")
+		}
+		defer db.Close()
+
+		mock.ExpectQuery("^SELECT COUNT\\(\\*\\) FROM `user` WHERE deleted_at IS NULL AND is_active = \\?$").
+			WithArgs(false).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(42))
+
+		dbHandle := sqlx.NewDb(db, "mysql")
+		// Initialize user store
+		userStore := &SQLStore{DB: dbHandle}
+		isActive := false
+		options := &ListOptions{
+			IsActive: &isActive,
+			Keyset:   true,
+			After:    &listCursor{Created: time.Now(), ID: 7},
+		}
+		total, err := userStore.Count(context.Background(), options)
+		if err != nil {
+			t.Error("Unexpected error.")
+		}
+
+		if total != 42 {
+			t.Error("Total should be 42")
+		}
+	})
+}
+
 func TestStoreCreate(t *testing.T) {
 	t.Run("Create user", func(t *testing.T) {
 
@@ -53,19 +206,30 @@ func TestStoreCreate(t *testing.T) {
 		}
 		defer db.Close()
 
-		mock.ExpectExec("^INSERT INTO user \\(uuid, first_name, last_name, email, is_active, created, modified\\) VALUES \\(UUID\\(\\), \\?, \\?, \\?, \\?, NOW\\(\\), NOW\\(\\)\\)").
-			WithArgs("User1FirstName", "User1LastName", "u1fn.u1ln@mail.test", true).
+		mock.ExpectExec("^INSERT INTO `user` \\(uuid, first_name, last_name, email, is_active, created, modified\\) VALUES \\(\\?, \\?, \\?, \\?, \\?, UTC_TIMESTAMP\\(\\), UTC_TIMESTAMP\\(\\)\\)").
+			WithArgs(sqlmock.AnyArg(), "User1FirstName", "User1LastName", "u1fn.u1ln@mail.test", true).
 			WillReturnResult(sqlmock.NewResult(1, 1))
+		// Created row, read back by UUID
+		rows := sqlmock.NewRows([]string{"id", "uuid", "first_name", "last_name", "email", "is_active", "created", "modified"}).
+			AddRow(1, "1e7aceca-9da3-11ea-bd4c-0242ac140002", "User1FirstName", "User1LastName", "u1fn.u1ln@mail.test", true, time.Now(), time.Now())
+		mock.ExpectQuery("^SELECT id, uuid, first_name, last_name, email, is_active, created, modified, deleted_at FROM `user` WHERE uuid = \\?").
+			WithArgs(sqlmock.AnyArg()).
+			WillReturnRows(rows)
 
 		dbHandle := sqlx.NewDb(db, "mysql")
 		// Initialize user store
-		userStore := &userStore{dbHandle}
+		userStore := &SQLStore{DB: dbHandle}
 		// Build user instance
 		user := &User{FirstName: "User1FirstName", LastName: "User1LastName", Email: "u1fn.u1ln@mail.test", IsActive: true}
-		err = userStore.Create(user)
+		err = userStore.Create(context.Background(), user)
 		if err != nil {
 			t.Error("Unexpected error.")
 		}
+
+		// Check the user was populated with the stored values
+		if user.ID != 1 || user.Created.IsZero() {
+			t.Error("User should be populated with the created row.")
+		}
 	})
 }
 
@@ -83,14 +247,14 @@ func TestStoreGet(t *testing.T) {
 		rows := sqlmock.NewRows([]string{"id", "uuid", "first_name", "last_name", "email", "is_active", "created", "modified"}).
 			AddRow(1, "1e7aceca-9da3-11ea-bd4c-0242ac140002", "User1FirstName", "User1LastName", "u1fn.u1ln@mail.test", true, time.Now(), time.Now())
 
-		mock.ExpectQuery("^SELECT id, uuid, first_name, last_name, email, is_active, created, modified FROM user WHERE uuid = \\?").
+		mock.ExpectQuery("^SELECT id, uuid, first_name, last_name, email, is_active, created, modified, deleted_at FROM `user` WHERE uuid = \\?").
 			WithArgs("1e7aceca-9da3-11ea-bd4c-0242ac140002").
 			WillReturnRows(rows)
 
 		dbHandle := sqlx.NewDb(db, "mysql")
 		// Initialize user store
-		userStore := &userStore{dbHandle}
-		user, err := userStore.Get("1e7aceca-9da3-11ea-bd4c-0242ac140002")
+		userStore := &SQLStore{DB: dbHandle}
+		user, err := userStore.Get(context.Background(), "1e7aceca-9da3-11ea-bd4c-0242ac140002", false)
 		if err != nil {
 			t.Error("Unexpected error.")
 		}
@@ -112,14 +276,175 @@ func TestStoreDelete(t *testing.T) {
 		}
 		defer db.Close()
 
-		mock.ExpectExec("^DELETE FROM user WHERE uuid = \\?").
+		mock.ExpectExec("^UPDATE `user` SET deleted_at = UTC_TIMESTAMP\\(\\), modified = UTC_TIMESTAMP\\(\\) WHERE uuid = \\? AND deleted_at IS NULL").
 			WithArgs("1e7aceca-9da3-11ea-bd4c-0242ac140002").
 			WillReturnResult(sqlmock.NewResult(1, 1))
 
 		dbHandle := sqlx.NewDb(db, "mysql")
 		// Initialize user store
-		userStore := &userStore{dbHandle}
-		err = userStore.Delete("1e7aceca-9da3-11ea-bd4c-0242ac140002")
+		userStore := &SQLStore{DB: dbHandle}
+		err = userStore.Delete(context.Background(), "1e7aceca-9da3-11ea-bd4c-0242ac140002")
+		if err != nil {
+			t.Error("Unexpected error.")
+		}
+	})
+}
+
+func TestStoreUpdate(t *testing.T) {
+	t.Run("Update user", func(t *testing.T) {
+
+		// Create a mock sql db connection
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Error("Error while opening mock SQL connection.")
+		}
+		defer db.Close()
+
+		mock.ExpectExec("^UPDATE `user` SET last_name = \\?, is_active = \\?, modified = UTC_TIMESTAMP\\(\\) WHERE uuid = \\?").
+			WithArgs("User1NewLastName", false, "1e7aceca-9da3-11ea-bd4c-0242ac140002").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		dbHandle := sqlx.NewDb(db, "mysql")
+		// Initialize user store
+		userStore := &SQLStore{DB: dbHandle}
+		changes := map[string]interface{}{"is_active": false, "last_name": "User1NewLastName", "id": 2}
+		err = userStore.Update(context.Background(), "1e7aceca-9da3-11ea-bd4c-0242ac140002", changes)
+		if err != nil {
+			t.Error("Unexpected error.")
+		}
+		if err = mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	})
+}
+
+func TestStoreUpdateNonExisting(t *testing.T) {
+	t.Run("Update non-existing user", func(t *testing.T) {
+
+		// Create a mock sql db connection
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Error("Error while opening mock SQL connection.")
+		}
+		defer db.Close()
+
+		mock.ExpectExec("^UPDATE `user` SET first_name = \\?, modified = UTC_TIMESTAMP\\(\\) WHERE uuid = \\?").
+			WithArgs("User1NewFirstName", "1e7aceca-9da3-11ea-bd4c-0242ac140002").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		dbHandle := sqlx.NewDb(db, "mysql")
+		// Initialize user store
+		userStore := &SQLStore{DB: dbHandle}
+		changes := map[string]interface{}{"first_name": "User1NewFirstName"}
+		err = userStore.Update(context.Background(), "1e7aceca-9da3-11ea-bd4c-0242ac140002", changes)
+		if err != sql.ErrNoRows {
+			t.Error("sql.ErrNoRows expected.")
+		}
+	})
+}
+
+func TestStoreCreateDuplicate(t *testing.T) {
+	// Multiple test cases, for the MariaDB and MySQL 8 message formats
+	var tests = []struct {
+		name    string
+		message string
+	}{
+		{"Create user - duplicate email (MariaDB)", "Duplicate entry 'u1fn.u1ln@mail.test' for key 'email'"},
+		{"Create user - duplicate email (MySQL 8)", "Duplicate entry 'u1fn.u1ln@mail.test' for key 'user.email'"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// Create a mock sql db connection
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Error("Error while opening mock SQL connection.")
+			}
+			defer db.Close()
+
+			mock.ExpectExec("^INSERT INTO `user`").
+				WillReturnError(&mysql.MySQLError{Number: 1062, Message: test.message})
+
+			dbHandle := sqlx.NewDb(db, "mysql")
+			// Initialize user store
+			userStore := &SQLStore{DB: dbHandle}
+			user := &User{FirstName: "User1FirstName", LastName: "User1LastName", Email: "u1fn.u1ln@mail.test", IsActive: true}
+			err = userStore.Create(context.Background(), user)
+
+			// Check the conflicting field
+			conflictErr, ok := err.(*ConflictError)
+			if !ok || conflictErr.Field != "email" {
+				t.Error("ConflictError on email expected.")
+			}
+		})
+	}
+}
+
+func TestStoreDeleteNonExisting(t *testing.T) {
+	t.Run("Delete unknown or already deleted user", func(t *testing.T) {
+
+		// Create a mock sql db connection
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Error("Error while opening mock SQL connection.")
+		}
+		defer db.Close()
+
+		mock.ExpectExec("^UPDATE `user` SET deleted_at = UTC_TIMESTAMP\\(\\), modified = UTC_TIMESTAMP\\(\\) WHERE uuid = \\? AND deleted_at IS NULL").
+			WithArgs("1e7aceca-9da3-11ea-bd4c-0242ac140002").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		dbHandle := sqlx.NewDb(db, "mysql")
+		// Initialize user store
+		userStore := &SQLStore{DB: dbHandle}
+		err = userStore.Delete(context.Background(), "1e7aceca-9da3-11ea-bd4c-0242ac140002")
+		if err != sql.ErrNoRows {
+			t.Error("sql.ErrNoRows expected.")
+		}
+	})
+}
+
+func TestStoreRestore(t *testing.T) {
+	t.Run("Restore user", func(t *testing.T) {
+
+		// Create a mock sql db connection
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Error("Error while opening mock SQL connection.")
+		}
+		defer db.Close()
+
+		mock.ExpectExec("^UPDATE `user` SET deleted_at = NULL, modified = UTC_TIMESTAMP\\(\\) WHERE uuid = \\? AND deleted_at IS NOT NULL").
+			WithArgs("1e7aceca-9da3-11ea-bd4c-0242ac140002").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		dbHandle := sqlx.NewDb(db, "mysql")
+		// Initialize user store
+		userStore := &SQLStore{DB: dbHandle}
+		err = userStore.Restore(context.Background(), "1e7aceca-9da3-11ea-bd4c-0242ac140002")
+		if err != nil {
+			t.Error("Unexpected error.")
+		}
+	})
+}
+
+func TestStorePurge(t *testing.T) {
+	t.Run("Purge user", func(t *testing.T) {
+
+		// Create a mock sql db connection
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Error("Error while opening mock SQL connection.")
+		}
+		defer db.Close()
+
+		mock.ExpectExec("^DELETE FROM `user` WHERE uuid = \\?").
+			WithArgs("1e7aceca-9da3-11ea-bd4c-0242ac140002").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		dbHandle := sqlx.NewDb(db, "mysql")
+		// Initialize user store
+		userStore := &SQLStore{DB: dbHandle}
+		err = userStore.Purge(context.Background(), "1e7aceca-9da3-11ea-bd4c-0242ac140002")
 		if err != nil {
 			t.Error("Unexpected error.")
 		}
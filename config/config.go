@@ -1,9 +1,14 @@
 package config
 
 import (
+	"bytes"
+	"errors"
+	"fmt"
 	"io/ioutil"
-	"log"
-	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
 	"gopkg.in/yaml.v2"
 )
@@ -13,47 +18,360 @@ type Configuration struct {
 	Server struct {
 		Hostname string
 		Port     string
+		// Bearer token required for admin operations (e.g. purging users); disabled if empty
+		AdminToken string `secret:"true"`
+		// HTTP server timeouts; zero means no timeout
+		ReadTimeout       time.Duration
+		ReadHeaderTimeout time.Duration
+		WriteTimeout      time.Duration
+		IdleTimeout       time.Duration
+		// Time allowed for the readiness checks of /readyz
+		ReadinessTimeout time.Duration
+		// On shutdown, time spent serving requests while failing the readiness probe
+		ShutdownDelay time.Duration
+		// Time allowed for in-flight requests to complete on shutdown
+		ShutdownTimeout time.Duration
 	}
 	Database struct {
+		// Database driver: mysql, postgres or sqlite
+		Driver   string
 		Hostname string
 		Port     int
 		Username string
-		Password string
-		Name     string
+		Password string `secret:"true"`
+		// Database name; the path of the database file with sqlite
+		Name string
+		// Apply pending schema migrations at startup
+		AutoMigrate bool
+
+		// Connection pool; zero MaxOpenConns and durations mean unlimited
+		MaxOpenConns    int
+		MaxIdleConns    int
+		ConnMaxLifetime time.Duration
+		ConnMaxIdleTime time.Duration
+		// Dial and I/O timeouts; zero means no timeout
+		DialTimeout  time.Duration
+		ReadTimeout  time.Duration
+		WriteTimeout time.Duration
+		// TLS mode: false, true (verify the server certificate), skip-verify or preferred
+		TLS string
+		// PEM files with the CA certificates and the client certificate/key; require TLS true or skip-verify
+		TLSCA   string
+		TLSCert string
+		TLSKey  string
+		// Connection character set and collation; driver defaults if empty
+		Charset   string
+		Collation string
+		// Extra DSN parameters, e.g. time_zone; overridden as comma separated key=value pairs
+		Params map[string]string
+		// Time allowed for store operations, cancelled afterwards; zero means no timeout.
+		// QueryTimeouts overrides it by operation, e.g. user.list: 10s
+		QueryTimeout  time.Duration
+		QueryTimeouts map[string]time.Duration
+		// Startup connection retries, with exponential backoff between RetryBackoff and RetryMaxBackoff;
+		// ConnectTimeout bounds all attempts, zero means no deadline
+		ConnectRetries  int
+		RetryBackoff    time.Duration
+		RetryMaxBackoff time.Duration
+		ConnectTimeout  time.Duration
+	}
+	Store struct {
+		// User store backend: sql (the database.driver database: mysql, postgres or sqlite), or memory for tests and development (data is lost on exit)
+		Backend string
+	}
+	Log struct {
+		// Minimum level of the logged messages: debug, info, warn or error
+		Level string `reload:"hot"`
+		// Line format: json or logfmt
+		Format string
+	}
+	Tracing struct {
+		// Span exporter: none, stdout, file or otlp
+		Exporter string
+		// OTLP/HTTP collector URL
+		Endpoint string
+		// Output of the file exporter
+		File string
+		// Service name reported with the spans
+		ServiceName string
+		// Fraction of new traces which are sampled, between 0 and 1
+		SampleRatio float64
+	}
+	Auth struct {
+		// Require JWT bearer tokens on the /v1 API; the admin token is accepted too
+		Enabled bool
+		// Shared secret of HS256 tokens, at least 32 bytes
+		Secret string `secret:"true"`
+		// Path or http(s) URL of the JWKS document with the keys of RS256 and ES256 tokens
+		JWKS string
+		// Required iss and aud claims; not checked if empty
+		Issuer   string
+		Audience string
+		// Clock skew allowed when checking the exp and nbf claims
+		Leeway time.Duration
+	}
+	Pagination struct {
+		// Key for signing pagination cursors; a random key is used if empty
+		CursorSecret string `secret:"true"`
+		// Page size used when the limit parameter is missing
+		DefaultLimit int `reload:"hot"`
+		// Largest accepted limit parameter
+		MaxLimit int `reload:"hot"`
 	}
 }
 
-// Config - global config variable
-var Config *Configuration
+// Defaults - returns the configuration used for keys missing from the file and overrides:
+//
+//	server.hostname:   localhost
+//	server.port:       8080
+//	server.readtimeout:       15s
+//	server.readheadertimeout: 5s
+//	server.writetimeout:      30s
+//	server.idletimeout:       60s
+//	server.readinesstimeout:  2s
+//	server.shutdowntimeout:   30s
+//	database.driver:   mysql
+//	database.hostname: localhost
+//	database.port:     3306
+//	database.maxopenconns:    10
+//	database.maxidleconns:    5
+//	database.connmaxlifetime: 5m
+//	database.dialtimeout:     10s
+//	database.tls:             false
+//	database.querytimeout:    5s
+//	database.connectretries:  10
+//	database.retrybackoff:    500ms
+//	database.retrymaxbackoff: 10s
+//	database.connecttimeout:  1m
+//	store.backend:           sql
+//	log.level:               info
+//	log.format:              json
+//	tracing.exporter:        none
+//	tracing.endpoint:        http://localhost:4318
+//	tracing.file:            traces.json
+//	tracing.servicename:     sample-rest-api
+//	tracing.sampleratio:     1
+//	auth.enabled:            false
+//	auth.leeway:             30s
+//	pagination.defaultlimit: 10
+//	pagination.maxlimit:     25
+func Defaults() *Configuration {
+	config := &Configuration{}
+	config.Server.Hostname = "localhost"
+	config.Server.Port = "8080"
+	config.Server.ReadTimeout = 15 * time.Second
+	config.Server.ReadHeaderTimeout = 5 * time.Second
+	config.Server.WriteTimeout = 30 * time.Second
+	config.Server.IdleTimeout = 60 * time.Second
+	config.Server.ReadinessTimeout = 2 * time.Second
+	config.Server.ShutdownTimeout = 30 * time.Second
+	config.Database.Driver = "mysql"
+	config.Database.Hostname = "localhost"
+	config.Database.Port = 3306
+	config.Database.MaxOpenConns = 10
+	config.Database.MaxIdleConns = 5
+	config.Database.ConnMaxLifetime = 5 * time.Minute
+	config.Database.DialTimeout = 10 * time.Second
+	config.Database.TLS = "false"
+	config.Database.QueryTimeout = 5 * time.Second
+	config.Database.ConnectRetries = 10
+	config.Database.RetryBackoff = 500 * time.Millisecond
+	config.Database.RetryMaxBackoff = 10 * time.Second
+	config.Database.ConnectTimeout = time.Minute
+	config.Store.Backend = "sql"
+	config.Log.Level = "info"
+	config.Log.Format = "json"
+	config.Tracing.Exporter = "none"
+	config.Tracing.Endpoint = "http://localhost:4318"
+	config.Tracing.File = "traces.json"
+	config.Tracing.ServiceName = "sample-rest-api"
+	config.Tracing.SampleRatio = 1
+	config.Auth.Leeway = 30 * time.Second
+	config.Pagination.DefaultLimit = 10
+	config.Pagination.MaxLimit = 25
+
+	return config
+}
 
 // Load - Reads configuration from YAML file and ENV vars
-func Load(filename string) {
+// Precedence, from lowest to highest: defaults, YAML file, environment variables (see EnvName),
+// command line flags (see RegisterFlags). Unknown YAML keys are rejected, secret references
+// are resolved (see SecretResolver) and the result is validated.
+func Load(filename string) (*Configuration, error) {
 	// Check config filename not empty
 	if len(filename) == 0 {
-		log.Println("Empty config filename.")
-		return
+		return nil, errors.New("empty config filename")
 	}
 
-	// Open config file
-	configFile, err := os.Open(filename)
+	// Read config file
+	bConfig, err := ioutil.ReadFile(filename)
 	if err != nil {
-		log.Println("Cannot open config file.")
-		return
+		return nil, fmt.Errorf("cannot read config file: %w", err)
+	}
+	if len(bytes.TrimSpace(bConfig)) == 0 {
+		return nil, fmt.Errorf("config file %s is empty", filename)
 	}
-	defer configFile.Close()
 
-	// Read config file
-	bConfig, err := ioutil.ReadAll(configFile)
+	// Unmarshal YAML content over the defaults
+	config := Defaults()
+	err = yaml.UnmarshalStrict(bConfig, config)
 	if err != nil {
-		log.Println(err)
-		return
+		return nil, fmt.Errorf("config file %s: %w", filename, err)
 	}
-	// Unmarshal YAML content
-	err = yaml.Unmarshal(bConfig, &Config)
+
+	// Apply environment variable and command line flag overrides
+	err = applyOverrides(config)
 	if err != nil {
-		log.Println(err)
-		return
+		return nil, err
+	}
+
+	// Resolve secret references, e.g. file:///run/secrets/db_password or env:DB_PASS
+	err = resolveSecrets(config)
+	if err != nil {
+		return nil, fmt.Errorf("config file %s: %w", filename, err)
+	}
+
+	err = config.Validate()
+	if err != nil {
+		return nil, fmt.Errorf("config file %s: %w", filename, err)
+	}
+
+	return config, nil
+}
+
+// Validate - checks required keys and value ranges; all problems are reported at once
+func (config *Configuration) Validate() error {
+	problems := make([]string, 0)
+
+	if port, err := strconv.Atoi(config.Server.Port); err != nil || port < 1 || port > 65535 {
+		problems = append(problems, "server.port: must be a number between 1 and 65535")
+	}
+	// The memory store backend does not connect to the database, and SQLite has no server
+	if config.Store.Backend != "memory" {
+		switch config.Database.Driver {
+		case "mysql", "postgres":
+			if config.Database.Hostname == "" {
+				problems = append(problems, "database.hostname: required")
+			}
+			if config.Database.Port < 1 || config.Database.Port > 65535 {
+				problems = append(problems, "database.port: must be between 1 and 65535")
+			}
+			if config.Database.Username == "" {
+				problems = append(problems, "database.username: required")
+			}
+		case "sqlite":
+		default:
+			problems = append(problems, "database.driver: must be one of mysql, postgres, sqlite")
+		}
+		if config.Database.Name == "" {
+			problems = append(problems, "database.name: required")
+		}
+	}
+	if config.Database.MaxOpenConns < 0 {
+		problems = append(problems, "database.maxopenconns: must not be negative")
+	}
+	if config.Database.MaxIdleConns < 0 {
+		problems = append(problems, "database.maxidleconns: must not be negative")
+	}
+	if config.Database.ConnectRetries < 0 {
+		problems = append(problems, "database.connectretries: must not be negative")
+	}
+	type duration struct {
+		key   string
+		value time.Duration
+	}
+	durations := []duration{
+		{"server.readtimeout", config.Server.ReadTimeout},
+		{"server.readheadertimeout", config.Server.ReadHeaderTimeout},
+		{"server.writetimeout", config.Server.WriteTimeout},
+		{"server.idletimeout", config.Server.IdleTimeout},
+		{"server.readinesstimeout", config.Server.ReadinessTimeout},
+		{"server.shutdowndelay", config.Server.ShutdownDelay},
+		{"server.shutdowntimeout", config.Server.ShutdownTimeout},
+		{"database.connmaxlifetime", config.Database.ConnMaxLifetime},
+		{"database.connmaxidletime", config.Database.ConnMaxIdleTime},
+		{"database.dialtimeout", config.Database.DialTimeout},
+		{"database.readtimeout", config.Database.ReadTimeout},
+		{"database.writetimeout", config.Database.WriteTimeout},
+		{"database.retrybackoff", config.Database.RetryBackoff},
+		{"database.retrymaxbackoff", config.Database.RetryMaxBackoff},
+		{"database.connecttimeout", config.Database.ConnectTimeout},
+		{"database.querytimeout", config.Database.QueryTimeout},
+		{"auth.leeway", config.Auth.Leeway},
+	}
+	operations := make([]string, 0, len(config.Database.QueryTimeouts))
+	for operation := range config.Database.QueryTimeouts {
+		operations = append(operations, operation)
+	}
+	sort.Strings(operations)
+	for _, operation := range operations {
+		durations = append(durations, duration{"database.querytimeouts." + operation, config.Database.QueryTimeouts[operation]})
+	}
+	for _, duration := range durations {
+		if duration.value < 0 {
+			problems = append(problems, duration.key+": must not be negative")
+		}
+	}
+	switch config.Database.TLS {
+	case "false", "preferred":
+		if config.Database.TLSCA != "" || config.Database.TLSCert != "" {
+			problems = append(problems, "database.tls: must be true or skip-verify when certificates are set")
+		}
+	case "true", "skip-verify":
+	default:
+		problems = append(problems, "database.tls: must be one of false, true, skip-verify, preferred")
+	}
+	if (config.Database.TLSCert == "") != (config.Database.TLSKey == "") {
+		problems = append(problems, "database.tlscert, database.tlskey: must be set together")
+	}
+
+	if config.Store.Backend != "sql" && config.Store.Backend != "memory" {
+		problems = append(problems, "store.backend: must be sql or memory")
+	}
+
+	switch strings.ToLower(config.Log.Level) {
+	case "debug", "info", "warn", "error":
+	default:
+		problems = append(problems, "log.level: must be one of debug, info, warn, error")
+	}
+	if config.Log.Format != "json" && config.Log.Format != "logfmt" {
+		problems = append(problems, "log.format: must be json or logfmt")
+	}
+
+	switch config.Tracing.Exporter {
+	case "none", "stdout":
+	case "file":
+		if config.Tracing.File == "" {
+			problems = append(problems, "tracing.file: required with the file exporter")
+		}
+	case "otlp":
+		if config.Tracing.Endpoint == "" {
+			problems = append(problems, "tracing.endpoint: required with the otlp exporter")
+		}
+	default:
+		problems = append(problems, "tracing.exporter: must be one of none, stdout, file, otlp")
+	}
+	if config.Tracing.SampleRatio < 0 || config.Tracing.SampleRatio > 1 {
+		problems = append(problems, "tracing.sampleratio: must be between 0 and 1")
+	}
+
+	if config.Auth.Enabled && config.Auth.Secret == "" && config.Auth.JWKS == "" {
+		problems = append(problems, "auth.secret, auth.jwks: one is required when auth is enabled")
+	}
+	if config.Auth.Secret != "" && len(config.Auth.Secret) < 32 {
+		problems = append(problems, "auth.secret: must be at least 32 bytes")
+	}
+
+	if config.Pagination.MaxLimit < 1 {
+		problems = append(problems, "pagination.maxlimit: must be at least 1")
+	}
+	if config.Pagination.DefaultLimit < 1 || config.Pagination.DefaultLimit > config.Pagination.MaxLimit {
+		problems = append(problems, "pagination.defaultlimit: must be between 1 and pagination.maxlimit")
+	}
+
+	if len(problems) > 0 {
+		return errors.New("invalid configuration: " + strings.Join(problems, "; "))
 	}
 
-	return
+	return nil
 }
@@ -8,25 +8,38 @@ import (
 	"testing"
 )
 
+// validContent - minimal valid configuration file
+const validContent = "database:\n  username: user\n  name: sample-rest-api\n"
+
 func TestLoadEmptyFilename(t *testing.T) {
 	t.Run("Load config file with empty filename", func(t *testing.T) {
-		Load("")
-		if Config != nil {
+		config, err := Load("")
+		if config != nil || err == nil {
 			t.Error("Config should be null on empty config file")
 		}
 	})
 }
 
+func TestLoadMissingFile(t *testing.T) {
+	t.Run("Load config file - missing file", func(t *testing.T) {
+		config, err := Load("/non/existing/config.yml")
+		if config != nil || err == nil || !strings.Contains(err.Error(), "/non/existing/config.yml") {
+			t.Error("Config should be null on missing config file, with the filename in the error.")
+		}
+	})
+}
+
 func TestLoadNoPermissions(t *testing.T) {
 	t.Run("Load config file without permissions", func(t *testing.T) {
 		file, fileErr := ioutil.TempFile("", "no_permissions_file")
 		if fileErr != nil {
 			t.Error("Could not create test file.")
 		}
+		defer os.Remove(file.Name())
 
 		file.Chmod(0000)
-		Load(file.Name())
-		if Config != nil {
+		config, err := Load(file.Name())
+		if config != nil || err == nil {
 			t.Error("Config should be null on config file without permissions.")
 		}
 	})
@@ -38,6 +51,7 @@ func TestLoadInvalidFile(t *testing.T) {
 		if fileErr != nil {
 			t.Error("Could not create test file.")
 		}
+		defer os.Remove(file.Name())
 
 		_, err := os.Open(file.Name())
 		if err != nil {
@@ -45,8 +59,8 @@ func TestLoadInvalidFile(t *testing.T) {
 
 		}
 
-		Load(file.Name())
-		if Config != nil {
+		config, err := Load(file.Name())
+		if config != nil || err == nil {
 			t.Error("Config should be null on read/unmarshal error.")
 		}
 	})
@@ -58,14 +72,81 @@ func TestValidConfig(t *testing.T) {
 		if fileErr != nil {
 			t.Error("Could not create test file.")
 		}
+		defer os.Remove(file.Name())
 
-		_, wErr := io.Copy(file, strings.NewReader("abc: def"))
+		_, wErr := io.Copy(file, strings.NewReader(validContent))
 		if wErr != nil {
 			t.Error("Could not write to test file.")
 		}
-		Load(file.Name())
-		if Config == nil {
+		config, err := Load(file.Name())
+		if config == nil || err != nil {
 			t.Error("Config should not be null on valid content.")
+			return
+		}
+
+		// Missing keys get their defaults
+		if config.Server.Hostname != "localhost" || config.Server.Port != "8080" || config.Database.Port != 3306 {
+			t.Error("Defaults should be applied.")
 		}
 	})
+
+	t.Run("Load config file - memory store without database", func(t *testing.T) {
+		filename := writeConfigFile(t, "store:\n  backend: memory\n")
+		defer os.Remove(filename)
+
+		config, err := Load(filename)
+		if config == nil || err != nil {
+			t.Error("Database keys should not be required with the memory store:", err)
+		}
+	})
+}
+
+func TestLoadUnknownKey(t *testing.T) {
+	t.Run("Load config file - unknown key", func(t *testing.T) {
+		filename := writeConfigFile(t, validContent+"  passwd: secret\n")
+		defer os.Remove(filename)
+
+		config, err := Load(filename)
+		if config != nil || err == nil || !strings.Contains(err.Error(), "passwd") {
+			t.Error("Unknown keys should be rejected.")
+		}
+	})
+}
+
+func TestValidate(t *testing.T) {
+	// Multiple test cases
+	var tests = []struct {
+		name    string
+		content string
+		problem string
+	}{
+		{"Missing required keys", "server:\n  port: 8080\n", "database.username: required"},
+		{"Server port out of range", validContent + "server:\n  port: 70000\n", "server.port"},
+		{"Database port out of range", "database:\n  username: user\n  name: db\n  port: 0\n", "database.port"},
+		{"Unknown log level", validContent + "log:\n  level: verbose\n", "log.level"},
+		{"Unknown log format", validContent + "log:\n  format: xml\n", "log.format"},
+		{"Negative pool size", validContent + "  maxopenconns: -1\n", "database.maxopenconns"},
+		{"Negative timeout", validContent + "  readtimeout: -1s\n", "database.readtimeout"},
+		{"Negative query timeout", validContent + "  querytimeouts:\n    user.list: -5s\n", "database.querytimeouts.user.list"},
+		{"Unknown TLS mode", validContent + "  tls: maybe\n", "database.tls"},
+		{"Certificates without TLS", validContent + "  tlsca: /etc/ssl/ca.pem\n", "database.tls"},
+		{"Client certificate without key", validContent + "  tls: \"true\"\n  tlscert: /etc/ssl/client.pem\n", "database.tlskey"},
+		{"Unknown store backend", validContent + "store:\n  backend: redis\n", "store.backend"},
+		{"Unknown database driver", validContent + "  driver: oracle\n", "database.driver"},
+		{"SQLite without database file", "database:\n  driver: sqlite\n", "database.name"},
+		{"Auth without keys", validContent + "auth:\n  enabled: true\n", "auth.secret, auth.jwks"},
+		{"Short auth secret", validContent + "auth:\n  secret: short\n", "auth.secret: must be at least 32 bytes"},
+		{"Negative auth leeway", validContent + "auth:\n  leeway: -1s\n", "auth.leeway"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			filename := writeConfigFile(t, test.content)
+			defer os.Remove(filename)
+
+			config, err := Load(filename)
+			if config != nil || err == nil || !strings.Contains(err.Error(), test.problem) {
+				t.Errorf("Expected validation error on %s, got %v", test.problem, err)
+			}
+		})
+	}
 }
@@ -0,0 +1,200 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EnvPrefix - prefix of the environment variables overriding configuration keys,
+// e.g. APP_DATABASE_PASSWORD overrides database.password
+const EnvPrefix = "APP_"
+
+// flagOverrides - configuration keys set through command line flags
+var flagOverrides = make(map[string]string)
+
+// flagValue - command line flag recording the value of a configuration key
+type flagValue struct {
+	key string
+}
+
+func (f *flagValue) String() string {
+	return ""
+}
+
+func (f *flagValue) Set(value string) error {
+	flagOverrides[f.key] = value
+	return nil
+}
+
+// RegisterFlags - registers a command line flag for every configuration key, e.g. -database.port;
+// flags take precedence over environment variables
+func RegisterFlags(flagSet *flag.FlagSet) {
+	for _, key := range Keys() {
+		flagSet.Var(&flagValue{key}, key, "overrides the "+key+" configuration key")
+	}
+}
+
+// Keys - returns the dotted names of all configuration keys, sorted
+func Keys() []string {
+	keys := make([]string, 0)
+	for key := range configFields(reflect.ValueOf(&Configuration{}).Elem(), "") {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// EnvName - returns the environment variable overriding the configuration key
+func EnvName(key string) string {
+	return EnvPrefix + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+}
+
+// applyOverrides - overrides configuration keys from environment variables, then from command line flags
+func applyOverrides(config *Configuration) error {
+	fields := configFields(reflect.ValueOf(config).Elem(), "")
+
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if value, ok := os.LookupEnv(EnvName(key)); ok {
+			if err := setField(fields[key].value, value); err != nil {
+				return fmt.Errorf("invalid value for %s: %w", EnvName(key), err)
+			}
+		}
+	}
+	for _, key := range keys {
+		if value, ok := flagOverrides[key]; ok {
+			if err := setField(fields[key].value, value); err != nil {
+				return fmt.Errorf("invalid value for -%s: %w", key, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// configField - leaf field of the configuration
+type configField struct {
+	value reflect.Value
+	// secret - the field is tagged with `secret:"true"`
+	secret bool
+	// hot - the field is tagged with `reload:"hot"`, so it can be changed without a restart
+	hot bool
+}
+
+// configFields - walks the configuration struct, returning its leaf fields keyed by their dotted YAML path
+func configFields(value reflect.Value, prefix string) map[string]configField {
+	fields := make(map[string]configField)
+	valueType := value.Type()
+	for i := 0; i < valueType.NumField(); i++ {
+		field := valueType.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		key := prefix + yamlKey(field)
+
+		// Nested sections; durations are leaves
+		if field.Type.Kind() == reflect.Struct && field.Type != reflect.TypeOf(time.Duration(0)) {
+			for nestedKey, nestedField := range configFields(value.Field(i), key+".") {
+				fields[nestedKey] = nestedField
+			}
+			continue
+		}
+		fields[key] = configField{
+			value:  value.Field(i),
+			secret: field.Tag.Get("secret") == "true",
+			hot:    field.Tag.Get("reload") == "hot",
+		}
+	}
+
+	return fields
+}
+
+// yamlKey - name of the field in the YAML file: the yaml tag if set, otherwise the lowercased field name
+func yamlKey(field reflect.StructField) string {
+	if name := strings.Split(field.Tag.Get("yaml"), ",")[0]; name != "" {
+		return name
+	}
+
+	return strings.ToLower(field.Name)
+}
+
+// setField - converts the string value to the type of the field and sets it
+func setField(field reflect.Value, value string) error {
+	switch {
+	case field.Type() == reflect.TypeOf(time.Duration(0)):
+		duration, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(duration))
+	case field.Kind() == reflect.String:
+		field.SetString(value)
+	case field.Kind() == reflect.Bool:
+		boolean, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		field.SetBool(boolean)
+	case field.Kind() >= reflect.Int && field.Kind() <= reflect.Int64:
+		integer, err := strconv.ParseInt(value, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetInt(integer)
+	case field.Kind() >= reflect.Uint && field.Kind() <= reflect.Uint64:
+		integer, err := strconv.ParseUint(value, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetUint(integer)
+	case field.Kind() == reflect.Float64:
+		float, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return err
+		}
+		field.SetFloat(float)
+	case field.Kind() == reflect.Slice && field.Type().Elem().Kind() == reflect.String:
+		// Comma separated list
+		items := make([]string, 0)
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		field.Set(reflect.ValueOf(items))
+	case field.Kind() == reflect.Map && field.Type().Key().Kind() == reflect.String:
+		// Comma separated list of key=value pairs; values are converted like fields
+		items := reflect.MakeMap(field.Type())
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item == "" {
+				continue
+			}
+			pair := strings.SplitN(item, "=", 2)
+			if len(pair) != 2 || pair[0] == "" {
+				return fmt.Errorf("expected key=value, got %q", item)
+			}
+			itemValue := reflect.New(field.Type().Elem()).Elem()
+			if err := setField(itemValue, pair[1]); err != nil {
+				return fmt.Errorf("%s: %w", pair[0], err)
+			}
+			items.SetMapIndex(reflect.ValueOf(pair[0]).Convert(field.Type().Key()), itemValue)
+		}
+		field.Set(items)
+	default:
+		return fmt.Errorf("unsupported type %s", field.Type())
+	}
+
+	return nil
+}
@@ -0,0 +1,111 @@
+package config
+
+import (
+	"flag"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeConfigFile - writes the content to a temporary config file, returning its name
+func writeConfigFile(t *testing.T, content string) string {
+	file, fileErr := ioutil.TempFile("", "config")
+	if fileErr != nil {
+		t.Error("Could not create test file.")
+	}
+	defer file.Close()
+
+	_, wErr := io.Copy(file, strings.NewReader(content))
+	if wErr != nil {
+		t.Error("Could not write to test file.")
+	}
+
+	return file.Name()
+}
+
+func TestEnvName(t *testing.T) {
+	t.Run("Environment variable name", func(t *testing.T) {
+		if EnvName("database.password") != "APP_DATABASE_PASSWORD" {
+			t.Error("Incorrect environment variable name.")
+		}
+	})
+}
+
+func TestLoadEnvOverrides(t *testing.T) {
+	t.Run("Load config file - env overrides", func(t *testing.T) {
+		filename := writeConfigFile(t, validContent+"  password: file\n  port: 3306\nserver:\n  port: 8080\n")
+		defer os.Remove(filename)
+
+		os.Setenv("APP_DATABASE_PASSWORD", "env")
+		os.Setenv("APP_DATABASE_PORT", "3307")
+		os.Setenv("APP_DATABASE_AUTOMIGRATE", "true")
+		os.Setenv("APP_DATABASE_CONNMAXLIFETIME", "90s")
+		os.Setenv("APP_DATABASE_PARAMS", "time_zone='+00:00', sql_mode=ANSI")
+		os.Setenv("APP_DATABASE_QUERYTIMEOUTS", "user.list=10s")
+		defer os.Unsetenv("APP_DATABASE_PASSWORD")
+		defer os.Unsetenv("APP_DATABASE_PORT")
+		defer os.Unsetenv("APP_DATABASE_AUTOMIGRATE")
+		defer os.Unsetenv("APP_DATABASE_CONNMAXLIFETIME")
+		defer os.Unsetenv("APP_DATABASE_PARAMS")
+		defer os.Unsetenv("APP_DATABASE_QUERYTIMEOUTS")
+
+		config, err := Load(filename)
+		if config == nil || err != nil {
+			t.Error("Config should not be null on valid content.")
+			return
+		}
+
+		if config.Database.Password != "env" || config.Database.Port != 3307 || !config.Database.AutoMigrate ||
+			config.Server.Port != "8080" {
+			t.Error("Environment variables should override the config file.")
+		}
+		if config.Database.ConnMaxLifetime != 90*time.Second || config.Database.Params["time_zone"] != "'+00:00'" ||
+			config.Database.Params["sql_mode"] != "ANSI" {
+			t.Error("Environment variables should override durations and params.")
+		}
+		if config.Database.QueryTimeouts["user.list"] != 10*time.Second {
+			t.Error("Environment variables should override query timeouts.")
+		}
+	})
+}
+
+func TestLoadInvalidEnvOverride(t *testing.T) {
+	t.Run("Load config file - invalid env override", func(t *testing.T) {
+		filename := writeConfigFile(t, validContent)
+		defer os.Remove(filename)
+
+		os.Setenv("APP_DATABASE_PORT", "abc")
+		defer os.Unsetenv("APP_DATABASE_PORT")
+
+		config, err := Load(filename)
+		if config != nil || err == nil || !strings.Contains(err.Error(), "APP_DATABASE_PORT") {
+			t.Error("Config should be null on invalid override.")
+		}
+	})
+}
+
+func TestLoadFlagOverrides(t *testing.T) {
+	t.Run("Load config file - flags override env", func(t *testing.T) {
+		filename := writeConfigFile(t, validContent+"server:\n  port: 8080\n")
+		defer os.Remove(filename)
+
+		os.Setenv("APP_SERVER_PORT", "8081")
+		defer os.Unsetenv("APP_SERVER_PORT")
+
+		flagSet := flag.NewFlagSet("test", flag.ContinueOnError)
+		RegisterFlags(flagSet)
+		err := flagSet.Parse([]string{"-server.port=8082"})
+		if err != nil {
+			t.Error("Unexpected error.")
+		}
+		defer delete(flagOverrides, "server.port")
+
+		config, _ := Load(filename)
+		if config == nil || config.Server.Port != "8082" {
+			t.Error("Flags should override environment variables.")
+		}
+	})
+}
@@ -0,0 +1,112 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// redacted - replaces secret values when printing the configuration
+const redacted = "[REDACTED]"
+
+// SecretResolver - resolves secret references of a scheme, e.g. env:DB_PASS; the reference
+// is passed without the scheme
+type SecretResolver interface {
+	Resolve(reference string) (string, error)
+}
+
+// SecretResolverFunc - adapter allowing plain functions to be used as secret resolvers
+type SecretResolverFunc func(reference string) (string, error)
+
+// Resolve - implements SecretResolver
+func (f SecretResolverFunc) Resolve(reference string) (string, error) {
+	return f(reference)
+}
+
+// secretResolvers - registered resolvers, by scheme
+var secretResolvers = map[string]SecretResolver{
+	"file": SecretResolverFunc(resolveFile),
+	"env":  SecretResolverFunc(resolveEnv),
+}
+
+// RegisterSecretResolver - registers a resolver for secret references of the given scheme
+func RegisterSecretResolver(scheme string, resolver SecretResolver) {
+	secretResolvers[scheme] = resolver
+}
+
+// resolveFile - reads the secret from a file, e.g. file:///run/secrets/db_password;
+// trailing line breaks are removed
+func resolveFile(reference string) (string, error) {
+	content, err := ioutil.ReadFile(strings.TrimPrefix(reference, "//"))
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimRight(string(content), "\r\n"), nil
+}
+
+// resolveEnv - reads the secret from an environment variable, e.g. env:DB_PASS
+func resolveEnv(reference string) (string, error) {
+	value, ok := os.LookupEnv(reference)
+	if !ok {
+		return "", fmt.Errorf("environment variable %s is not set", reference)
+	}
+
+	return value, nil
+}
+
+// resolveSecrets - replaces secret references in the fields tagged with `secret:"true"` by their values;
+// values without a registered scheme are used as they are
+func resolveSecrets(config *Configuration) error {
+	fields := configFields(reflect.ValueOf(config).Elem(), "")
+
+	keys := make([]string, 0, len(fields))
+	for key, field := range fields {
+		if field.secret && field.value.Kind() == reflect.String {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		value := fields[key].value.String()
+		index := strings.Index(value, ":")
+		if index < 0 {
+			continue
+		}
+		resolver, ok := secretResolvers[value[:index]]
+		if !ok {
+			continue
+		}
+
+		secret, err := resolver.Resolve(value[index+1:])
+		if err != nil {
+			return fmt.Errorf("%s: cannot resolve secret %s: %w", key, value[:index], err)
+		}
+		fields[key].value.SetString(secret)
+	}
+
+	return nil
+}
+
+// String - returns the configuration as YAML, with secrets redacted, so it can be logged
+func (config Configuration) String() string {
+	// The receiver is a copy, so it can be redacted in place
+	for _, field := range configFields(reflect.ValueOf(&config).Elem(), "") {
+		if field.secret && field.value.Kind() == reflect.String && field.value.String() != "" {
+			field.value.SetString(redacted)
+		}
+	}
+
+	content, err := yaml.Marshal(&config)
+	if err != nil {
+		return "<invalid configuration: " + err.Error() + ">"
+	}
+
+	return string(content)
+}
@@ -0,0 +1,81 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestLoadSecrets(t *testing.T) {
+	t.Run("Load config file - secret references", func(t *testing.T) {
+		secretFile := writeConfigFile(t, "file-password\n")
+		defer os.Remove(secretFile)
+		filename := writeConfigFile(t, validContent+"  password: file://"+secretFile+"\nserver:\n  admintoken: env:TEST_ADMIN_TOKEN\n")
+		defer os.Remove(filename)
+
+		os.Setenv("TEST_ADMIN_TOKEN", "env-token")
+		defer os.Unsetenv("TEST_ADMIN_TOKEN")
+
+		config, err := Load(filename)
+		if config == nil || err != nil {
+			t.Error(err)
+			return
+		}
+
+		if config.Database.Password != "file-password" || config.Server.AdminToken != "env-token" {
+			t.Error("Secret references should be resolved.")
+		}
+	})
+}
+
+func TestLoadUnresolvableSecret(t *testing.T) {
+	t.Run("Load config file - unresolvable secret", func(t *testing.T) {
+		filename := writeConfigFile(t, validContent+"  password: file:///non/existing/secret\n")
+		defer os.Remove(filename)
+
+		config, err := Load(filename)
+		if config != nil || err == nil || !strings.Contains(err.Error(), "database.password") {
+			t.Error("Unresolvable secrets should be reported with their key.")
+		}
+	})
+}
+
+func TestRegisterSecretResolver(t *testing.T) {
+	t.Run("Custom secret resolver", func(t *testing.T) {
+		RegisterSecretResolver("vault", SecretResolverFunc(func(reference string) (string, error) {
+			if reference != "secret/db#password" {
+				return "", errors.New("unknown secret")
+			}
+			return "vault-password", nil
+		}))
+		defer delete(secretResolvers, "vault")
+
+		filename := writeConfigFile(t, validContent+"  password: vault:secret/db#password\n")
+		defer os.Remove(filename)
+
+		config, err := Load(filename)
+		if config == nil || err != nil || config.Database.Password != "vault-password" {
+			t.Error("Custom resolver should be used.")
+		}
+	})
+}
+
+func TestStringRedactsSecrets(t *testing.T) {
+	t.Run("Configuration string - secrets redacted", func(t *testing.T) {
+		config := Defaults()
+		config.Database.Password = "p4ssw0rd"
+		config.Pagination.CursorSecret = "s3cr3t"
+
+		for _, output := range []string{config.String(), (*config).String()} {
+			if strings.Contains(output, "p4ssw0rd") || strings.Contains(output, "s3cr3t") || !strings.Contains(output, redacted) {
+				t.Error("Secrets should be redacted.")
+			}
+		}
+
+		// The configuration itself must not be modified
+		if config.Database.Password != "p4ssw0rd" {
+			t.Error("Configuration should not be modified.")
+		}
+	})
+}
@@ -0,0 +1,169 @@
+package config
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"os/signal"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// Store - holds the current configuration, reloaded from its file on demand.
+// Only keys tagged with `reload:"hot"` are applied on reload; changes to other keys require a restart.
+type Store struct {
+	filename string
+	current  atomic.Value
+
+	// mu - serializes reloads and guards the subscribers
+	mu          sync.Mutex
+	subscribers []subscriber
+}
+
+// subscriber - callback for changes of the keys starting with prefix
+type subscriber struct {
+	prefix   string
+	callback func(config *Configuration)
+}
+
+// ReloadResult - outcome of a successful reload
+type ReloadResult struct {
+	// Applied - changed keys which are now in effect
+	Applied []string
+	// RestartRequired - changed keys which are ignored until the next restart
+	RestartRequired []string
+}
+
+// NewStore - loads the configuration file into a new store
+func NewStore(filename string) (*Store, error) {
+	config, err := Load(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	store := &Store{filename: filename}
+	store.current.Store(config)
+
+	return store, nil
+}
+
+// Get - returns the current configuration; it must not be modified
+func (s *Store) Get() *Configuration {
+	return s.current.Load().(*Configuration)
+}
+
+// Subscribe - calls the callback with the new configuration whenever a key starting with the prefix
+// (e.g. "pagination.") changes on reload. Callbacks run synchronously, during the reload.
+func (s *Store) Subscribe(prefix string, callback func(config *Configuration)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.subscribers = append(s.subscribers, subscriber{prefix, callback})
+}
+
+// Reload - reloads and validates the configuration file, then atomically swaps in the hot reloadable changes.
+// On error the current configuration is kept.
+func (s *Store) Reload() (*ReloadResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	loaded, err := Load(s.filename)
+	if err != nil {
+		return nil, err
+	}
+
+	// Keys which cannot be reloaded keep their current values
+	current := s.Get()
+	result := &ReloadResult{Applied: make([]string, 0), RestartRequired: make([]string, 0)}
+	currentFields := configFields(reflect.ValueOf(current).Elem(), "")
+	for key, field := range configFields(reflect.ValueOf(loaded).Elem(), "") {
+		if reflect.DeepEqual(field.value.Interface(), currentFields[key].value.Interface()) {
+			continue
+		}
+		if field.hot {
+			result.Applied = append(result.Applied, key)
+			continue
+		}
+		result.RestartRequired = append(result.RestartRequired, key)
+		field.value.Set(currentFields[key].value)
+	}
+	sort.Strings(result.Applied)
+	sort.Strings(result.RestartRequired)
+	if len(result.Applied) == 0 {
+		return result, nil
+	}
+
+	// The hot reloadable keys are validated as part of the whole configuration
+	err = loaded.Validate()
+	if err != nil {
+		return nil, err
+	}
+	s.current.Store(loaded)
+
+	// Notify the subscribers of the applied keys
+	for _, subscriber := range s.subscribers {
+		for _, key := range result.Applied {
+			if strings.HasPrefix(key, subscriber.prefix) {
+				subscriber.callback(loaded)
+				break
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// Watch - reloads the configuration on SIGHUP, or when the modification time of the file changes
+// (checked at the given interval); stops when the context is done
+func (s *Store) Watch(ctx context.Context, interval time.Duration) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+	defer signal.Stop(signals)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	modified := s.modTime()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-signals:
+			slog.Info("Received SIGHUP, reloading configuration", "path", s.filename)
+		case <-ticker.C:
+			latest := s.modTime()
+			if latest.Equal(modified) {
+				continue
+			}
+			modified = latest
+			slog.Info("Configuration file changed, reloading configuration", "path", s.filename)
+		}
+
+		result, err := s.Reload()
+		if err != nil {
+			slog.Error("Configuration not reloaded", "path", s.filename, "error", err)
+			continue
+		}
+		if len(result.Applied) > 0 {
+			slog.Info("Configuration reloaded", "path", s.filename, "keys", result.Applied)
+		}
+		if len(result.RestartRequired) > 0 {
+			slog.Warn("Configuration changes require a restart", "path", s.filename, "keys", result.RestartRequired)
+		}
+	}
+}
+
+// modTime - modification time of the configuration file, zero if it cannot be read
+func (s *Store) modTime() time.Time {
+	info, err := os.Stat(s.filename)
+	if err != nil {
+		return time.Time{}
+	}
+
+	return info.ModTime()
+}
@@ -0,0 +1,109 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestStoreReload(t *testing.T) {
+	t.Run("Reload config file - hot keys applied", func(t *testing.T) {
+		filename := writeConfigFile(t, validContent)
+		defer os.Remove(filename)
+
+		store, err := NewStore(filename)
+		if err != nil {
+			t.Fatal("Config store creation failed:", err)
+		}
+		var notified *Configuration
+		store.Subscribe("pagination.", func(config *Configuration) {
+			notified = config
+		})
+		var unrelated bool
+		store.Subscribe("server.", func(config *Configuration) {
+			unrelated = true
+		})
+
+		content := validContent + "pagination:\n  defaultlimit: 20\n  maxlimit: 50\n"
+		if err := ioutil.WriteFile(filename, []byte(content), 0600); err != nil {
+			t.Fatal("Could not write to test file.")
+		}
+		result, err := store.Reload()
+		if err != nil {
+			t.Fatal("Reload failed:", err)
+		}
+
+		if !reflect.DeepEqual(result.Applied, []string{"pagination.defaultlimit", "pagination.maxlimit"}) {
+			t.Error("Incorrect applied keys:", result.Applied)
+		}
+		if store.Get().Pagination.MaxLimit != 50 || store.Get().Pagination.DefaultLimit != 20 {
+			t.Error("Hot keys not applied.")
+		}
+		if notified != store.Get() {
+			t.Error("Subscriber not notified with the new configuration.")
+		}
+		if unrelated {
+			t.Error("Unrelated subscriber notified.")
+		}
+	})
+
+	t.Run("Reload config file - restart required", func(t *testing.T) {
+		filename := writeConfigFile(t, validContent)
+		defer os.Remove(filename)
+
+		store, err := NewStore(filename)
+		if err != nil {
+			t.Fatal("Config store creation failed:", err)
+		}
+		var notified bool
+		store.Subscribe("", func(config *Configuration) {
+			notified = true
+		})
+
+		content := validContent + "  hostname: db.example.com\nserver:\n  port: 9090\n"
+		if err := ioutil.WriteFile(filename, []byte(content), 0600); err != nil {
+			t.Fatal("Could not write to test file.")
+		}
+		result, err := store.Reload()
+		if err != nil {
+			t.Fatal("Reload failed:", err)
+		}
+
+		if len(result.Applied) != 0 {
+			t.Error("Incorrect applied keys:", result.Applied)
+		}
+		if !reflect.DeepEqual(result.RestartRequired, []string{"database.hostname", "server.port"}) {
+			t.Error("Incorrect restart required keys:", result.RestartRequired)
+		}
+		if store.Get().Database.Hostname != "localhost" || store.Get().Server.Port != "8080" {
+			t.Error("Restart required keys applied.")
+		}
+		if notified {
+			t.Error("Subscriber notified without applied keys.")
+		}
+	})
+
+	t.Run("Reload config file - invalid", func(t *testing.T) {
+		filename := writeConfigFile(t, validContent)
+		defer os.Remove(filename)
+
+		store, err := NewStore(filename)
+		if err != nil {
+			t.Fatal("Config store creation failed:", err)
+		}
+		current := store.Get()
+
+		content := validContent + "pagination:\n  defaultlimit: 30\n  maxlimit: 20\n"
+		if err := ioutil.WriteFile(filename, []byte(content), 0600); err != nil {
+			t.Fatal("Could not write to test file.")
+		}
+		if _, err := store.Reload(); err == nil {
+			t.Error("Invalid configuration reloaded.")
+		}
+
+		if store.Get() != current {
+			t.Error("Current configuration replaced.")
+		}
+	})
+}
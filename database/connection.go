@@ -1,21 +1,166 @@
 package database
 
 import (
-	"log"
+	"context"
+	"log/slog"
+	"math/rand"
+	"time"
 
 	"github.com/jmoiron/sqlx"
-	// Provide mysql driver for sql.Open
-	_ "github.com/go-sql-driver/mysql"
 )
 
-// Connect - Creates a database handle instance using the given DSN
-func Connect(dsn string) *sqlx.DB {
-	// sqlx's Connect handles both opening the connection and Ping
-	dbHandle, err := sqlx.Connect("mysql", dsn)
+// TLS modes
+const (
+	// TLSDisabled - plain text connections
+	TLSDisabled = "false"
+	// TLSVerify - TLS connections, verifying the server certificate
+	TLSVerify = "true"
+	// TLSSkipVerify - TLS connections, without verifying the server certificate
+	TLSSkipVerify = "skip-verify"
+	// TLSPreferred - TLS connections if the server supports them, without verifying the server certificate
+	TLSPreferred = "preferred"
+)
+
+// Options - connection, DSN and pool settings
+type Options struct {
+	// Driver - one of the drivers; empty means MySQL
+	Driver string
+
+	Hostname string
+	Port     int
+	Username string
+	Password string
+	// Name - database name; the path of the database file with SQLite
+	Name string
+
+	// MaxOpenConns - maximum number of open connections; zero means unlimited
+	MaxOpenConns int
+	// MaxIdleConns - maximum number of idle connections; zero keeps no idle connections
+	MaxIdleConns int
+	// ConnMaxLifetime, ConnMaxIdleTime - connections are closed after these durations; zero means never
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
+
+	// Timeouts; zero means no timeout
+	DialTimeout  time.Duration
+	ReadTimeout  time.Duration
+	WriteTimeout time.Duration
+
+	// TLS - one of the TLS modes; empty disables TLS
+	TLS string
+	// TLSCA - PEM file with the CA certificates verifying the server; the system pool is used if empty
+	TLSCA string
+	// TLSCert, TLSKey - PEM files with the client certificate and its private key
+	TLSCert string
+	TLSKey  string
+
+	// Charset, Collation - connection character set and collation; driver defaults if empty
+	Charset   string
+	Collation string
+	// Params - extra DSN parameters, e.g. system variables such as time_zone
+	Params map[string]string
+
+	// ConnectRetries - number of retries after a failed first connection attempt
+	ConnectRetries int
+	// RetryBackoff - wait before the first retry, doubled after every retry up to RetryMaxBackoff
+	RetryBackoff    time.Duration
+	RetryMaxBackoff time.Duration
+	// ConnectTimeout - overall deadline for connecting, retries included; zero means no deadline
+	ConnectTimeout time.Duration
+}
+
+// DSN - builds the data source name for the driver
+func (options *Options) DSN() (string, error) {
+	dialect, err := DialectFor(options.Driver)
 	if err != nil {
-		log.Println(err)
+		return "", err
+	}
+
+	return dialect.DSN(options)
+}
+
+// configurePool - applies the pool settings to the database handle
+func configurePool(dbHandle *sqlx.DB, options *Options) {
+	dbHandle.SetMaxOpenConns(options.MaxOpenConns)
+	dbHandle.SetMaxIdleConns(options.MaxIdleConns)
+	dbHandle.SetConnMaxLifetime(options.ConnMaxLifetime)
+	dbHandle.SetConnMaxIdleTime(options.ConnMaxIdleTime)
+}
+
+// nextBackoff - doubles the backoff, up to the maximum if set
+func nextBackoff(backoff time.Duration, max time.Duration) time.Duration {
+	backoff *= 2
+	if max > 0 && backoff > max {
+		return max
+	}
+
+	return backoff
+}
+
+// jitter - returns a random duration between half the backoff and the full backoff,
+// so instances started together do not retry in lockstep
+func jitter(backoff time.Duration) time.Duration {
+	if backoff <= 1 {
+		return backoff
+	}
+
+	return backoff/2 + time.Duration(rand.Int63n(int64(backoff/2)+1))
+}
+
+// Connect - Creates a database handle instance using the given options.
+// Failed connection attempts are retried with exponential backoff until the retries are exhausted,
+// the connect timeout expires or the context is cancelled.
+func Connect(ctx context.Context, options *Options) *sqlx.DB {
+	dialect, err := DialectFor(options.Driver)
+	if err != nil {
+		slog.Error("Invalid database driver", "error", err)
+		return nil
+	}
+	dsn, err := dialect.DSN(options)
+	if err != nil {
+		slog.Error("Invalid database options", "driver", dialect.Name(), "error", err)
 		return nil
 	}
 
-	return dbHandle
+	// Configure the pool before the first connection is opened by Ping
+	dbHandle, err := sqlx.Open(dialect.DriverName(), dsn)
+	if err != nil {
+		slog.Error("Cannot open database", "driver", dialect.Name(), "error", err)
+		return nil
+	}
+	configurePool(dbHandle, options)
+
+	if options.ConnectTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, options.ConnectTimeout)
+		defer cancel()
+	}
+
+	backoff := options.RetryBackoff
+	for attempt := 1; ; attempt++ {
+		err = dbHandle.PingContext(ctx)
+		if err == nil {
+			return dbHandle
+		}
+		if attempt > options.ConnectRetries {
+			slog.Error("Database connection failed, giving up", "attempt", attempt, "error", err)
+			break
+		}
+
+		wait := jitter(backoff)
+		slog.Warn("Database connection failed, retrying", "attempt", attempt, "retry_in", wait.Round(time.Millisecond), "error", err)
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			slog.Error("Database connection aborted", "attempt", attempt, "error", ctx.Err())
+			dbHandle.Close()
+			return nil
+		case <-timer.C:
+		}
+		backoff = nextBackoff(backoff, options.RetryMaxBackoff)
+	}
+
+	dbHandle.Close()
+	return nil
 }
@@ -1,34 +1,194 @@
 package database
 
 import (
+	"context"
+	"io/ioutil"
+	"os"
 	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-sql-driver/mysql"
+	"github.com/jmoiron/sqlx"
 )
 
 func TestConnect(t *testing.T) {
 	// Multiple test cases
 	var tests = []struct {
 		name         string
-		dsn          string
+		options      *Options
 		isNull       bool
 		errorMessage string
 	}{
-		{"Empty DSN",
-			"",
+		{"Empty options",
+			&Options{},
 			true,
-			"Database handle should be null on empty DSN",
+			"Database handle should be null on empty options",
 		},
-		{"Invalid DSN",
-			"mysql:",
+		{"Invalid TLS mode",
+			&Options{Hostname: "localhost", Port: 3306, TLS: "maybe"},
 			true,
-			"Database handle should be null on invalid DSN",
+			"Database handle should be null on invalid TLS mode",
 		},
 	}
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
-			dbHandle := Connect(test.dsn)
+			dbHandle := Connect(context.Background(), test.options)
 			if (dbHandle == nil) != test.isNull {
 				t.Error(test.errorMessage)
 			}
 		})
 	}
 }
+
+func TestConnectRetry(t *testing.T) {
+	// Nothing listens on port 1, so every attempt is refused
+	unreachable := func() *Options {
+		return &Options{Hostname: "127.0.0.1", Port: 1, DialTimeout: time.Second, RetryBackoff: time.Millisecond}
+	}
+
+	t.Run("Connect - retries exhausted", func(t *testing.T) {
+		options := unreachable()
+		options.ConnectRetries = 2
+		if Connect(context.Background(), options) != nil {
+			t.Error("Database handle should be null when the retries are exhausted")
+		}
+	})
+
+	t.Run("Connect - connect timeout", func(t *testing.T) {
+		options := unreachable()
+		options.ConnectRetries = 1000
+		options.RetryBackoff = 10 * time.Millisecond
+		options.ConnectTimeout = 50 * time.Millisecond
+
+		start := time.Now()
+		if Connect(context.Background(), options) != nil {
+			t.Error("Database handle should be null after the connect timeout")
+		}
+		if time.Since(start) > 5*time.Second {
+			t.Error("Connect timeout not applied.")
+		}
+	})
+
+	t.Run("Connect - cancelled context", func(t *testing.T) {
+		options := unreachable()
+		options.ConnectRetries = 1000
+		options.RetryBackoff = time.Hour
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(50*time.Millisecond, cancel)
+
+		start := time.Now()
+		if Connect(ctx, options) != nil {
+			t.Error("Database handle should be null on cancelled context")
+		}
+		if time.Since(start) > 5*time.Second {
+			t.Error("Cancelled context did not stop the retries.")
+		}
+	})
+}
+
+func TestBackoff(t *testing.T) {
+	t.Run("Exponential backoff", func(t *testing.T) {
+		if nextBackoff(time.Second, 0) != 2*time.Second {
+			t.Error("Backoff should double.")
+		}
+		if nextBackoff(8*time.Second, 10*time.Second) != 10*time.Second {
+			t.Error("Backoff should be capped at the maximum.")
+		}
+	})
+
+	t.Run("Jitter", func(t *testing.T) {
+		for i := 0; i < 100; i++ {
+			if wait := jitter(time.Second); wait < 500*time.Millisecond || wait > time.Second {
+				t.Fatal("Jitter out of range:", wait)
+			}
+		}
+	})
+}
+
+func TestDSN(t *testing.T) {
+	t.Run("DSN options", func(t *testing.T) {
+		options := &Options{
+			Hostname:     "db.example.com",
+			Port:         3307,
+			Username:     "user",
+			Password:     "password",
+			Name:         "sample-rest-api",
+			DialTimeout:  5 * time.Second,
+			ReadTimeout:  30 * time.Second,
+			WriteTimeout: 15 * time.Second,
+			TLS:          TLSSkipVerify,
+			Charset:      "utf8mb4",
+			Collation:    "utf8mb4_unicode_ci",
+			Params:       map[string]string{"time_zone": "'+00:00'"},
+		}
+		dsn, err := options.DSN()
+		if err != nil {
+			t.Fatal("DSN failed:", err)
+		}
+
+		mysqlConfig, err := mysql.ParseDSN(dsn)
+		if err != nil {
+			t.Fatal("Invalid DSN:", err)
+		}
+		if mysqlConfig.Addr != "db.example.com:3307" || mysqlConfig.User != "user" || mysqlConfig.DBName != "sample-rest-api" {
+			t.Error("Incorrect connection settings:", dsn)
+		}
+		if mysqlConfig.Timeout != 5*time.Second || mysqlConfig.ReadTimeout != 30*time.Second ||
+			mysqlConfig.WriteTimeout != 15*time.Second {
+			t.Error("Incorrect timeouts:", dsn)
+		}
+		if mysqlConfig.TLSConfig != TLSSkipVerify {
+			t.Error("Incorrect TLS mode:", dsn)
+		}
+		if mysqlConfig.Collation != "utf8mb4_unicode_ci" || mysqlConfig.Params["charset"] != "utf8mb4" {
+			t.Error("Incorrect charset or collation:", dsn)
+		}
+		if mysqlConfig.Params["time_zone"] != "'+00:00'" {
+			t.Error("Missing extra params:", dsn)
+		}
+		if !mysqlConfig.ParseTime {
+			t.Error("Times are not parsed:", dsn)
+		}
+		if !mysqlConfig.ClientFoundRows {
+			t.Error("Matched rows should be reported as affected:", dsn)
+		}
+	})
+
+	t.Run("DSN - missing TLS CA", func(t *testing.T) {
+		options := &Options{TLS: TLSVerify, TLSCA: "/nonexistent/ca.pem"}
+		if _, err := options.DSN(); err == nil {
+			t.Error("DSN should fail on missing TLS CA")
+		}
+	})
+
+	t.Run("DSN - invalid TLS CA", func(t *testing.T) {
+		file, err := ioutil.TempFile("", "ca")
+		if err != nil {
+			t.Fatal("Could not create test file.")
+		}
+		file.Close()
+		defer os.Remove(file.Name())
+
+		options := &Options{TLS: TLSVerify, TLSCA: file.Name()}
+		if _, err := options.DSN(); err == nil {
+			t.Error("DSN should fail on TLS CA without certificates")
+		}
+	})
+}
+
+func TestConfigurePool(t *testing.T) {
+	t.Run("Pool settings", func(t *testing.T) {
+		mockDB, _, err := sqlmock.New()
+		if err != nil {
+			t.Fatal("Could not create database mock.")
+		}
+		dbHandle := sqlx.NewDb(mockDB, "mysql")
+		defer dbHandle.Close()
+
+		configurePool(dbHandle, &Options{MaxOpenConns: 7, MaxIdleConns: 3, ConnMaxLifetime: time.Minute})
+		if dbHandle.Stats().MaxOpenConnections != 7 {
+			t.Error("Max open connections not applied.")
+		}
+	})
+}
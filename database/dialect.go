@@ -0,0 +1,80 @@
+package database
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Database drivers, the values of the database.driver configuration key
+const (
+	// DriverMySQL - MySQL and MariaDB
+	DriverMySQL = "mysql"
+	// DriverPostgres - PostgreSQL
+	DriverPostgres = "postgres"
+	// DriverSQLite - SQLite database file, no server needed
+	DriverSQLite = "sqlite"
+)
+
+// Dialect - connection and SQL differences between the supported databases.
+// Queries are written with ? placeholders, converted for the driver by sqlx.Rebind.
+type Dialect interface {
+	// Name - the driver name used in the configuration, e.g. postgres
+	Name() string
+	// DriverName - name of the database/sql driver
+	DriverName() string
+	// DSN - builds the data source name from the connection options
+	DSN(options *Options) (string, error)
+	// Quote - quotes an identifier, e.g. the user table, a reserved word in PostgreSQL
+	Quote(identifier string) string
+	// Now - SQL expression for the current time, as stored in the datetime columns
+	Now() string
+	// CaseInsensitive - SQL expression comparing the expression case insensitively, like the email unique key
+	CaseInsensitive(expression string) string
+	// DuplicateKey - returns the name of the unique key, if the error reports a unique constraint violation
+	DuplicateKey(err error) (string, bool)
+}
+
+// dialects - supported dialects, by driver name
+var dialects = map[string]Dialect{
+	DriverMySQL:    mysqlDialect{},
+	DriverPostgres: postgresDialect{},
+	DriverSQLite:   sqliteDialect{},
+}
+
+// Drivers - names of the supported drivers, sorted
+func Drivers() []string {
+	drivers := make([]string, 0, len(dialects))
+	for driver := range dialects {
+		drivers = append(drivers, driver)
+	}
+	sort.Strings(drivers)
+
+	return drivers
+}
+
+// DialectFor - returns the dialect of the driver; an empty driver means MySQL
+func DialectFor(driver string) (Dialect, error) {
+	if driver == "" {
+		driver = DriverMySQL
+	}
+	dialect, ok := dialects[driver]
+	if !ok {
+		return nil, fmt.Errorf("unknown database driver %q", driver)
+	}
+
+	return dialect, nil
+}
+
+// DialectOf - returns the dialect of the database handle, from its database/sql driver.
+// Handles with other drivers, e.g. mocks, get the MySQL dialect.
+func DialectOf(db *sqlx.DB) Dialect {
+	for _, dialect := range dialects {
+		if dialect.DriverName() == db.DriverName() {
+			return dialect
+		}
+	}
+
+	return mysqlDialect{}
+}
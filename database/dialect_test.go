@@ -0,0 +1,155 @@
+package database
+
+import (
+	"context"
+	"net/url"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/jmoiron/sqlx"
+)
+
+func TestDialectFor(t *testing.T) {
+	// Multiple test cases
+	var tests = []struct {
+		name       string
+		driver     string
+		driverName string
+		isError    bool
+	}{
+		{"Default driver", "", "mysql", false},
+		{"MySQL", DriverMySQL, "mysql", false},
+		{"PostgreSQL", DriverPostgres, "pgx", false},
+		{"SQLite", DriverSQLite, "sqlite", false},
+		{"Unknown driver", "oracle", "", true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dialect, err := DialectFor(test.driver)
+			if (err != nil) != test.isError {
+				t.Fatal("Unexpected error:", err)
+			}
+			if !test.isError && dialect.DriverName() != test.driverName {
+				t.Error("Incorrect database/sql driver:", dialect.DriverName())
+			}
+		})
+	}
+
+	t.Run("Dialect of a database handle", func(t *testing.T) {
+		if DialectOf(sqlx.NewDb(nil, "pgx")).Name() != DriverPostgres {
+			t.Error("PostgreSQL handle should get the PostgreSQL dialect.")
+		}
+		if DialectOf(sqlx.NewDb(nil, "sqlmock")).Name() != DriverMySQL {
+			t.Error("Unknown drivers should get the MySQL dialect.")
+		}
+	})
+}
+
+func TestPostgresDSN(t *testing.T) {
+	t.Run("PostgreSQL DSN options", func(t *testing.T) {
+		options := &Options{
+			Driver:      DriverPostgres,
+			Hostname:    "db.example.com",
+			Port:        5432,
+			Username:    "user",
+			Password:    "p@ss word",
+			Name:        "sample-rest-api",
+			DialTimeout: 1500 * time.Millisecond,
+			TLS:         TLSVerify,
+			TLSCA:       "/etc/ssl/ca.pem",
+			Charset:     "UTF8",
+			Params:      map[string]string{"application_name": "api"},
+		}
+		dsn, err := options.DSN()
+		if err != nil {
+			t.Fatal("DSN failed:", err)
+		}
+
+		parsed, err := url.Parse(dsn)
+		if err != nil {
+			t.Fatal("Invalid DSN:", err)
+		}
+		password, _ := parsed.User.Password()
+		if parsed.Host != "db.example.com:5432" || parsed.User.Username() != "user" || password != "p@ss word" ||
+			parsed.Path != "/sample-rest-api" {
+			t.Error("Incorrect connection settings:", dsn)
+		}
+		query := parsed.Query()
+		if query.Get("sslmode") != "verify-full" || query.Get("sslrootcert") != "/etc/ssl/ca.pem" {
+			t.Error("Incorrect TLS settings:", dsn)
+		}
+		if query.Get("connect_timeout") != "2" {
+			t.Error("Dial timeout should be rounded up to seconds:", dsn)
+		}
+		if query.Get("client_encoding") != "UTF8" || query.Get("application_name") != "api" {
+			t.Error("Missing parameters:", dsn)
+		}
+	})
+
+	t.Run("PostgreSQL DSN - unknown TLS mode", func(t *testing.T) {
+		options := &Options{Driver: DriverPostgres, TLS: "maybe"}
+		if _, err := options.DSN(); err == nil {
+			t.Error("DSN should fail on unknown TLS mode")
+		}
+	})
+}
+
+func TestSQLiteDSN(t *testing.T) {
+	t.Run("SQLite DSN options", func(t *testing.T) {
+		options := &Options{Driver: DriverSQLite, Name: "/var/lib/api/users.db", DialTimeout: 5 * time.Second}
+		dsn, err := options.DSN()
+		if err != nil {
+			t.Fatal("DSN failed:", err)
+		}
+
+		parsed, err := url.Parse(dsn)
+		if err != nil {
+			t.Fatal("Invalid DSN:", err)
+		}
+		if parsed.Scheme != "file" || parsed.Path != "/var/lib/api/users.db" {
+			t.Error("Incorrect database file:", dsn)
+		}
+		query := parsed.Query()
+		if query.Get("_time_format") != "sqlite" || len(query["_pragma"]) != 2 || query["_pragma"][1] != "busy_timeout(5000)" {
+			t.Error("Incorrect parameters:", dsn)
+		}
+	})
+}
+
+func TestDuplicateKey(t *testing.T) {
+	t.Run("MySQL duplicate entry", func(t *testing.T) {
+		err := &mysql.MySQLError{Number: 1062, Message: "Duplicate entry 'a@mail.test' for key 'user.email'"}
+		if key, ok := (mysqlDialect{}).DuplicateKey(err); !ok || key != "email" {
+			t.Error("Incorrect duplicate key:", key)
+		}
+		if _, ok := (mysqlDialect{}).DuplicateKey(&mysql.MySQLError{Number: 1045}); ok {
+			t.Error("Other errors are not duplicate keys.")
+		}
+	})
+
+	t.Run("SQLite unique constraint", func(t *testing.T) {
+		dbHandle := Connect(context.Background(), &Options{Driver: DriverSQLite, Name: filepath.Join(t.TempDir(), "test.db")})
+		if dbHandle == nil {
+			t.Fatal("Could not open SQLite database.")
+		}
+		defer dbHandle.Close()
+
+		dbHandle.MustExec(`CREATE TABLE "user" (email varchar(255) NOT NULL UNIQUE)`)
+		dbHandle.MustExec(`INSERT INTO "user" (email) VALUES ('a@mail.test')`)
+		_, err := dbHandle.Exec(`INSERT INTO "user" (email) VALUES ('a@mail.test')`)
+		if key, ok := (sqliteDialect{}).DuplicateKey(err); !ok || key != "email" {
+			t.Error("Incorrect duplicate key:", key, err)
+		}
+
+		// Violations of unnamed constraints cannot be mapped to a field
+		dbHandle.MustExec(`CREATE TABLE pair (a int, b int)`)
+		dbHandle.MustExec(`CREATE UNIQUE INDEX pair_a_b ON pair ((a + b))`)
+		dbHandle.MustExec(`INSERT INTO pair VALUES (1, 2)`)
+		_, err = dbHandle.Exec(`INSERT INTO pair VALUES (2, 1)`)
+		if key, ok := (sqliteDialect{}).DuplicateKey(err); ok {
+			t.Error("Unparsable violations should not be reported:", key, err)
+		}
+	})
+}
@@ -0,0 +1,302 @@
+package migrate
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"sample-rest-api/database"
+)
+
+// migrationFiles - SQL migrations embedded in the binary, in a directory per driver
+//
+//go:embed migrations/*/*.sql
+var migrationFiles embed.FS
+
+// SourceDir - directory holding the migration directories of the drivers, relative to the project root
+const SourceDir = "database/migrate/migrations"
+
+// migrationFilename - <version>_<name>.<up|down>.sql
+var migrationFilename = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+// migrationName - allowed names for new migrations
+var migrationName = regexp.MustCompile(`^[a-z0-9_]+$`)
+
+// Migration - versioned schema change
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Status - state of a migration in the database
+type Status struct {
+	Migration
+	Applied   bool
+	AppliedAt time.Time
+}
+
+// Migrator - applies and rolls back migrations, tracking them in the schema_migrations table
+type Migrator struct {
+	DB         *sqlx.DB
+	Migrations []Migration
+}
+
+// New - creates a migrator using the migrations embedded in the binary for the driver of the database
+func New(db *sqlx.DB) (*Migrator, error) {
+	migrations, err := Load(migrationFiles, "migrations/"+database.DialectOf(db).Name())
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migrator{
+		DB:         db,
+		Migrations: migrations,
+	}, nil
+}
+
+// Load - reads the migrations from the given directory, sorted by version
+func Load(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	migrationsByVersion := make(map[int64]*Migration)
+	for _, entry := range entries {
+		matches := migrationFilename.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || matches == nil {
+			continue
+		}
+		version, _ := strconv.ParseInt(matches[1], 10, 64)
+		content, err := fs.ReadFile(fsys, dir+"/"+entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		migration, ok := migrationsByVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: matches[2]}
+			migrationsByVersion[version] = migration
+		}
+		if migration.Name != matches[2] {
+			return nil, fmt.Errorf("migration %d has conflicting names %q and %q", version, migration.Name, matches[2])
+		}
+		if matches[3] == "up" {
+			migration.Up = string(content)
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(migrationsByVersion))
+	for _, migration := range migrationsByVersion {
+		if migration.Up == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up script", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+// Up - applies all pending migrations, in order; returns the applied migrations
+func (m *Migrator) Up() ([]Migration, error) {
+	statuses, err := m.Status()
+	if err != nil {
+		return nil, err
+	}
+
+	applied := make([]Migration, 0)
+	for _, status := range statuses {
+		if status.Applied {
+			continue
+		}
+		err := m.run(status.Migration, status.Up,
+			`INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, ?, `+database.DialectOf(m.DB).Now()+`)`,
+			status.Version, status.Name)
+		if err != nil {
+			return applied, err
+		}
+		applied = append(applied, status.Migration)
+	}
+
+	return applied, nil
+}
+
+// Down - rolls back the most recently applied migration; returns nil if none is applied
+func (m *Migrator) Down() (*Migration, error) {
+	statuses, err := m.Status()
+	if err != nil {
+		return nil, err
+	}
+
+	for i := len(statuses) - 1; i >= 0; i-- {
+		status := statuses[i]
+		if !status.Applied {
+			continue
+		}
+		if status.Down == "" {
+			return nil, fmt.Errorf("migration %d_%s has no down script", status.Version, status.Name)
+		}
+		err := m.run(status.Migration, status.Down, `DELETE FROM schema_migrations WHERE version = ?`, status.Version)
+		if err != nil {
+			return nil, err
+		}
+
+		return &status.Migration, nil
+	}
+
+	return nil, nil
+}
+
+// Status - returns the state of every known migration, in order
+func (m *Migrator) Status() ([]Status, error) {
+	err := m.ensureTable()
+	if err != nil {
+		return nil, err
+	}
+
+	rows := make([]struct {
+		Version   int64     `db:"version"`
+		AppliedAt time.Time `db:"applied_at"`
+	}, 0)
+	err = m.DB.Select(&rows, `SELECT version, applied_at FROM schema_migrations ORDER BY version`)
+	if err != nil {
+		return nil, err
+	}
+	appliedAt := make(map[int64]time.Time)
+	for _, row := range rows {
+		appliedAt[row.Version] = row.AppliedAt
+	}
+
+	statuses := make([]Status, 0, len(m.Migrations))
+	for _, migration := range m.Migrations {
+		at, applied := appliedAt[migration.Version]
+		statuses = append(statuses, Status{Migration: migration, Applied: applied, AppliedAt: at})
+	}
+
+	return statuses, nil
+}
+
+// Version - returns the version of the most recently applied migration, 0 if none
+func (m *Migrator) Version() (int64, error) {
+	err := m.ensureTable()
+	if err != nil {
+		return 0, err
+	}
+
+	var version int64
+	err = m.DB.Get(&version, `SELECT COALESCE(MAX(version), 0) FROM schema_migrations`)
+	if err != nil {
+		return 0, err
+	}
+
+	return version, nil
+}
+
+// ensureTable - creates the schema_migrations tracking table, if missing; timestamp is a type of every supported database
+func (m *Migrator) ensureTable() error {
+	_, err := m.DB.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+		version bigint NOT NULL,
+		name varchar(255) NOT NULL,
+		applied_at timestamp NOT NULL,
+		PRIMARY KEY (version)
+	)`)
+
+	return err
+}
+
+// run - executes the migration script and records the change in schema_migrations, in a transaction.
+// MySQL commits DDL statements implicitly, so scripts should keep to one schema change each;
+// PostgreSQL and SQLite roll back the whole migration on failure.
+func (m *Migrator) run(migration Migration, script string, trackingQuery string, trackingArgs ...interface{}) error {
+	tx, err := m.DB.Beginx()
+	if err != nil {
+		return err
+	}
+
+	for _, statement := range splitStatements(script) {
+		if _, err := tx.Exec(statement); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("migration %d_%s: %w", migration.Version, migration.Name, err)
+		}
+	}
+	if _, err := tx.Exec(tx.Rebind(trackingQuery), trackingArgs...); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("migration %d_%s: %w", migration.Version, migration.Name, err)
+	}
+
+	return tx.Commit()
+}
+
+// splitStatements - splits a script into statements terminated by a semicolon at the end of a line
+func splitStatements(script string) []string {
+	statements := make([]string, 0)
+	var current strings.Builder
+	for _, line := range strings.Split(script, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if current.Len() == 0 && (trimmed == "" || strings.HasPrefix(trimmed, "--")) {
+			continue
+		}
+		current.WriteString(line)
+		current.WriteString("\n")
+		if strings.HasSuffix(trimmed, ";") {
+			statements = append(statements, strings.TrimSuffix(strings.TrimSpace(current.String()), ";"))
+			current.Reset()
+		}
+	}
+	if rest := strings.TrimSpace(current.String()); rest != "" {
+		statements = append(statements, rest)
+	}
+
+	return statements
+}
+
+// Create - creates empty up and down scripts for a new migration in the given directory,
+// numbered after the latest existing migration; returns the paths of the created files
+func Create(dir string, name string) ([]string, error) {
+	name = strings.ToLower(strings.ReplaceAll(strings.TrimSpace(name), " ", "_"))
+	if !migrationName.MatchString(name) {
+		return nil, fmt.Errorf("invalid migration name %q, use lowercase letters, digits and underscores", name)
+	}
+
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var latest int64
+	for _, entry := range entries {
+		if matches := migrationFilename.FindStringSubmatch(entry.Name()); matches != nil {
+			version, _ := strconv.ParseInt(matches[1], 10, 64)
+			if version > latest {
+				latest = version
+			}
+		}
+	}
+
+	paths := make([]string, 0, 2)
+	for _, direction := range []string{"up", "down"} {
+		path := filepath.Join(dir, fmt.Sprintf("%04d_%s.%s.sql", latest+1, name, direction))
+		err := ioutil.WriteFile(path, []byte("-- "+direction+" migration for "+name+"\n"), 0644)
+		if err != nil {
+			return paths, err
+		}
+		paths = append(paths, path)
+	}
+
+	return paths, nil
+}
@@ -0,0 +1,225 @@
+package migrate
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+
+	"sample-rest-api/database"
+)
+
+func TestLoadEmbedded(t *testing.T) {
+	t.Run("Load embedded migrations", func(t *testing.T) {
+		migrator, err := New(&sqlx.DB{})
+		if err != nil {
+			t.Error("Unexpected error.")
+			return
+		}
+
+		if len(migrator.Migrations) == 0 || migrator.Migrations[0].Version != 1 || migrator.Migrations[0].Down == "" {
+			t.Error("Embedded migrations should be loaded.")
+		}
+	})
+}
+
+func TestLoad(t *testing.T) {
+	// Multiple test cases
+	var tests = []struct {
+		name     string
+		files    fstest.MapFS
+		versions []int64
+		isError  bool
+	}{
+		{"Sorted by version",
+			fstest.MapFS{
+				"m/0002_b.up.sql":   {Data: []byte("B")},
+				"m/0001_a.up.sql":   {Data: []byte("A")},
+				"m/0001_a.down.sql": {Data: []byte("-A")},
+				"m/README.md":       {Data: []byte("ignored")},
+			},
+			[]int64{1, 2},
+			false,
+		},
+		{"Missing up script",
+			fstest.MapFS{"m/0001_a.down.sql": {Data: []byte("-A")}},
+			nil,
+			true,
+		},
+		{"Conflicting names",
+			fstest.MapFS{
+				"m/0001_a.up.sql":   {Data: []byte("A")},
+				"m/0001_b.down.sql": {Data: []byte("-B")},
+			},
+			nil,
+			true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			migrations, err := Load(test.files, "m")
+			if (err != nil) != test.isError {
+				t.Error("Unexpected error result.")
+				return
+			}
+			for i, version := range test.versions {
+				if migrations[i].Version != version {
+					t.Error("Migrations should be sorted by version.")
+				}
+			}
+		})
+	}
+}
+
+func TestUp(t *testing.T) {
+	t.Run("Apply pending migrations", func(t *testing.T) {
+		// Create a mock sql db connection
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Error("Error while opening mock SQL connection.")
+		}
+		defer db.Close()
+
+		// Version 1 is already applied
+		mock.ExpectExec("^CREATE TABLE IF NOT EXISTS schema_migrations").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery("^SELECT version, applied_at FROM schema_migrations").
+			WillReturnRows(sqlmock.NewRows([]string{"version", "applied_at"}).AddRow(1, time.Now()))
+		mock.ExpectBegin()
+		mock.ExpectExec("^CREATE TABLE b").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("^INSERT INTO b").WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec("^INSERT INTO schema_migrations \\(version, name, applied_at\\) VALUES \\(\\?, \\?, UTC_TIMESTAMP\\(\\)\\)").
+			WithArgs(2, "b").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		migrator := &Migrator{
+			DB: sqlx.NewDb(db, "mysql"),
+			Migrations: []Migration{
+				{Version: 1, Name: "a", Up: "CREATE TABLE a (id int);"},
+				{Version: 2, Name: "b", Up: "-- Table b\nCREATE TABLE b (\n  id int\n);\nINSERT INTO b VALUES (1);\n"},
+			},
+		}
+		applied, err := migrator.Up()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		if len(applied) != 1 || applied[0].Version != 2 {
+			t.Error("Only the pending migration should be applied.")
+		}
+		if err = mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	})
+}
+
+func TestDown(t *testing.T) {
+	t.Run("Roll back the latest migration", func(t *testing.T) {
+		// Create a mock sql db connection
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Error("Error while opening mock SQL connection.")
+		}
+		defer db.Close()
+
+		mock.ExpectExec("^CREATE TABLE IF NOT EXISTS schema_migrations").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery("^SELECT version, applied_at FROM schema_migrations").
+			WillReturnRows(sqlmock.NewRows([]string{"version", "applied_at"}).AddRow(1, time.Now()).AddRow(2, time.Now()))
+		mock.ExpectBegin()
+		mock.ExpectExec("^DROP TABLE b").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("^DELETE FROM schema_migrations WHERE version = \\?").
+			WithArgs(2).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		migrator := &Migrator{
+			DB: sqlx.NewDb(db, "mysql"),
+			Migrations: []Migration{
+				{Version: 1, Name: "a", Up: "CREATE TABLE a (id int);", Down: "DROP TABLE a;"},
+				{Version: 2, Name: "b", Up: "CREATE TABLE b (id int);", Down: "DROP TABLE b;"},
+			},
+		}
+		migration, err := migrator.Down()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		if migration == nil || migration.Version != 2 {
+			t.Error("The latest migration should be rolled back.")
+		}
+		if err = mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	})
+}
+
+func TestEmbeddedSQLite(t *testing.T) {
+	t.Run("Apply and roll back the embedded migrations - SQLite", func(t *testing.T) {
+		dbHandle := database.Connect(context.Background(), &database.Options{
+			Driver: database.DriverSQLite,
+			Name:   filepath.Join(t.TempDir(), "test.db"),
+		})
+		if dbHandle == nil {
+			t.Fatal("Could not open SQLite database.")
+		}
+		defer dbHandle.Close()
+
+		migrator, err := New(dbHandle)
+		if err != nil {
+			t.Fatal(err)
+		}
+		applied, err := migrator.Up()
+		if err != nil {
+			t.Fatal(err)
+		}
+		version, err := migrator.Version()
+		if err != nil || len(applied) != len(migrator.Migrations) || version != applied[len(applied)-1].Version {
+			t.Error("All migrations should be applied:", version, err)
+		}
+		statuses, err := migrator.Status()
+		if err != nil || !statuses[0].Applied || statuses[0].AppliedAt.IsZero() {
+			t.Error("Incorrect status:", statuses, err)
+		}
+
+		for range applied {
+			if _, err := migrator.Down(); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if version, _ := migrator.Version(); version != 0 {
+			t.Error("All migrations should be rolled back, version:", version)
+		}
+	})
+}
+
+func TestCreate(t *testing.T) {
+	t.Run("Create migration files", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "migrations")
+		if err != nil {
+			t.Error("Could not create test directory.")
+		}
+		defer os.RemoveAll(dir)
+		ioutil.WriteFile(filepath.Join(dir, "0007_existing.up.sql"), []byte(""), 0644)
+
+		paths, err := Create(dir, "Add user index")
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		if len(paths) != 2 || filepath.Base(paths[0]) != "0008_add_user_index.up.sql" ||
+			filepath.Base(paths[1]) != "0008_add_user_index.down.sql" {
+			t.Error("Migration files should be numbered after the latest migration.")
+		}
+	})
+}
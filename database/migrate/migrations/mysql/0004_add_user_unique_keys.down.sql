@@ -0,0 +1,2 @@
+-- The unique keys belong to the table definition of migration 1 and are kept
+DO 0;
@@ -0,0 +1,26 @@
+-- Tables created before the unique keys existed (e.g. from the former init.sql dump) get them here;
+-- tables created by migration 1 already have them, so each key is only added if missing.
+-- Duplicate uuids or emails are not removed: the migration fails before changing the table, naming them,
+-- and the duplicates are left for the operator to resolve. MySQL has no way to raise an error from a plain
+-- script, so the check selects an unknown column named after the duplicates.
+SET @check_duplicates = (SELECT IF(COUNT(*) = 0, 'DO 0',
+    CONCAT('SELECT `Duplicate user uuids or emails must be resolved before adding the unique keys: ',
+      REPLACE(GROUP_CONCAT(duplicate SEPARATOR ', '), '`', ''), '`'))
+  FROM (
+    SELECT `uuid` AS duplicate FROM `user` GROUP BY `uuid` HAVING COUNT(*) > 1
+    UNION ALL
+    SELECT `email` FROM `user` GROUP BY `email` HAVING COUNT(*) > 1
+  ) duplicates);
+PREPARE check_duplicates FROM @check_duplicates;
+EXECUTE check_duplicates;
+DEALLOCATE PREPARE check_duplicates;
+SET @add_uuid_key = (SELECT IF(COUNT(*) = 0, 'ALTER TABLE `user` ADD UNIQUE KEY `uuid` (`uuid`)', 'DO 0')
+  FROM information_schema.statistics WHERE table_schema = DATABASE() AND table_name = 'user' AND index_name = 'uuid');
+PREPARE add_uuid_key FROM @add_uuid_key;
+EXECUTE add_uuid_key;
+DEALLOCATE PREPARE add_uuid_key;
+SET @add_email_key = (SELECT IF(COUNT(*) = 0, 'ALTER TABLE `user` ADD UNIQUE KEY `email` (`email`)', 'DO 0')
+  FROM information_schema.statistics WHERE table_schema = DATABASE() AND table_name = 'user' AND index_name = 'email');
+PREPARE add_email_key FROM @add_email_key;
+EXECUTE add_email_key;
+DEALLOCATE PREPARE add_email_key;
@@ -0,0 +1,2 @@
+-- Nothing was changed by the up migration
+DO 0;
@@ -0,0 +1,2 @@
+-- The email unique key is case insensitive with the default collations; only PostgreSQL needs an index on lower(email)
+DO 0;
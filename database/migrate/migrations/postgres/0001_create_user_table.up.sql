@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS "user" (
+  id serial NOT NULL,
+  uuid varchar(36) NOT NULL,
+  first_name varchar(255) NOT NULL,
+  last_name varchar(255) NOT NULL,
+  email varchar(255) NOT NULL,
+  is_active boolean NOT NULL DEFAULT false,
+  created timestamp NOT NULL,
+  modified timestamp NOT NULL,
+  PRIMARY KEY (id),
+  CONSTRAINT user_uuid_key UNIQUE (uuid),
+  CONSTRAINT user_email_key UNIQUE (email)
+);
@@ -0,0 +1 @@
+CREATE INDEX created_id ON "user" (created, id);
@@ -0,0 +1,2 @@
+-- The unique keys are created by migration 1; only MySQL tables may predate them
+SELECT 1;
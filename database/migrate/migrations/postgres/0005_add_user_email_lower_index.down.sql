@@ -0,0 +1,2 @@
+DROP INDEX user_email_key;
+ALTER TABLE "user" ADD CONSTRAINT user_email_key UNIQUE (email);
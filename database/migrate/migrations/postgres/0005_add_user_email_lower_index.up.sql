@@ -0,0 +1,6 @@
+-- Emails are unique regardless of their case, as on MySQL and SQLite: the constraint is replaced by a unique index
+-- on lower(email), under the same name so violations are still reported on the email field.
+-- Emails differing only in case make the migration fail; resolve them first, e.g. find them with
+-- SELECT lower(email) FROM "user" GROUP BY lower(email) HAVING COUNT(*) > 1
+ALTER TABLE "user" DROP CONSTRAINT user_email_key;
+CREATE UNIQUE INDEX user_email_key ON "user" (lower(email));
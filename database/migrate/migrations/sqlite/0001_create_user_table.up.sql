@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS "user" (
+  id integer NOT NULL PRIMARY KEY AUTOINCREMENT,
+  uuid varchar(36) NOT NULL UNIQUE,
+  first_name varchar(255) NOT NULL,
+  last_name varchar(255) NOT NULL,
+  email varchar(255) NOT NULL COLLATE NOCASE UNIQUE,
+  is_active boolean NOT NULL DEFAULT 0,
+  created datetime NOT NULL,
+  modified datetime NOT NULL
+);
@@ -0,0 +1 @@
+DROP INDEX created_id;
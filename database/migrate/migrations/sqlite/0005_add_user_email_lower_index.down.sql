@@ -0,0 +1,2 @@
+-- Nothing was changed by the up migration
+SELECT 1;
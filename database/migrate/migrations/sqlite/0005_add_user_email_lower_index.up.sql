@@ -0,0 +1,2 @@
+-- The email column has the NOCASE collation, so its unique key is case insensitive; only PostgreSQL needs an index on lower(email)
+SELECT 1;
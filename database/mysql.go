@@ -0,0 +1,141 @@
+package database
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// mysqlErrDuplicateEntry - MySQL error number for unique constraint violations
+const mysqlErrDuplicateEntry = 1062
+
+// tlsConfigName - name of the TLS configuration registered with the driver for custom certificates
+const tlsConfigName = "custom"
+
+// mysqlDialect - MySQL and MariaDB, with the go-sql-driver/mysql driver
+type mysqlDialect struct{}
+
+// Name - the driver name used in the configuration
+func (mysqlDialect) Name() string {
+	return DriverMySQL
+}
+
+// DriverName - name of the database/sql driver
+func (mysqlDialect) DriverName() string {
+	return "mysql"
+}
+
+// DSN - builds the MySQL DSN; custom TLS certificates are registered with the driver
+func (mysqlDialect) DSN(options *Options) (string, error) {
+	mysqlConfig := mysql.NewConfig()
+	mysqlConfig.User = options.Username
+	mysqlConfig.Passwd = options.Password
+	mysqlConfig.Net = "tcp"
+	mysqlConfig.Addr = options.Hostname + ":" + strconv.Itoa(options.Port)
+	mysqlConfig.DBName = options.Name
+	mysqlConfig.ParseTime = true
+	mysqlConfig.AllowNativePasswords = true
+	// Report matched rows as affected, so updates writing identical values still find their row
+	mysqlConfig.ClientFoundRows = true
+	mysqlConfig.Timeout = options.DialTimeout
+	mysqlConfig.ReadTimeout = options.ReadTimeout
+	mysqlConfig.WriteTimeout = options.WriteTimeout
+	if options.Collation != "" {
+		mysqlConfig.Collation = options.Collation
+	}
+
+	mysqlConfig.Params = make(map[string]string)
+	for key, value := range options.Params {
+		mysqlConfig.Params[key] = value
+	}
+	if options.Charset != "" {
+		mysqlConfig.Params["charset"] = options.Charset
+	}
+
+	tlsConfig, err := mysqlTLSConfig(options)
+	if err != nil {
+		return "", err
+	}
+	mysqlConfig.TLSConfig = tlsConfig
+
+	return mysqlConfig.FormatDSN(), nil
+}
+
+// Quote - quotes an identifier with backticks
+func (mysqlDialect) Quote(identifier string) string {
+	return "`" + identifier + "`"
+}
+
+// Now - current time in UTC, as the driver reads DATETIME columns back as UTC
+func (mysqlDialect) Now() string {
+	return "UTC_TIMESTAMP()"
+}
+
+// CaseInsensitive - the expression itself, as the default collations are case insensitive
+func (mysqlDialect) CaseInsensitive(expression string) string {
+	return expression
+}
+
+// DuplicateKey - returns the key of duplicate entry errors
+func (mysqlDialect) DuplicateKey(err error) (string, bool) {
+	var mysqlErr *mysql.MySQLError
+	if !errors.As(err, &mysqlErr) || mysqlErr.Number != mysqlErrDuplicateEntry {
+		return "", false
+	}
+
+	// Message format: Duplicate entry '<value>' for key '<key>' (MySQL 8 prefixes the key with the table name)
+	key := mysqlErr.Message[strings.LastIndex(mysqlErr.Message, " ")+1:]
+	key = strings.Trim(key, "'")
+
+	return key[strings.LastIndex(key, ".")+1:], true
+}
+
+// mysqlTLSConfig - returns the TLS configuration name for the DSN
+func mysqlTLSConfig(options *Options) (string, error) {
+	switch options.TLS {
+	case "", TLSDisabled:
+		return TLSDisabled, nil
+	case TLSPreferred:
+		return TLSPreferred, nil
+	case TLSVerify, TLSSkipVerify:
+	default:
+		return "", fmt.Errorf("unknown TLS mode %q", options.TLS)
+	}
+
+	// Without custom certificates the driver's own configurations are used
+	if options.TLSCA == "" && options.TLSCert == "" && options.TLSKey == "" {
+		return options.TLS, nil
+	}
+
+	tlsConfig := &tls.Config{InsecureSkipVerify: options.TLS == TLSSkipVerify}
+	if options.TLSCA != "" {
+		pem, err := ioutil.ReadFile(options.TLSCA)
+		if err != nil {
+			return "", fmt.Errorf("cannot read TLS CA: %w", err)
+		}
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(pem) {
+			return "", errors.New("no certificates found in TLS CA " + options.TLSCA)
+		}
+	}
+	if options.TLSCert != "" || options.TLSKey != "" {
+		certificate, err := tls.LoadX509KeyPair(options.TLSCert, options.TLSKey)
+		if err != nil {
+			return "", fmt.Errorf("cannot load TLS client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{certificate}
+	}
+
+	err := mysql.RegisterTLSConfig(tlsConfigName, tlsConfig)
+	if err != nil {
+		return "", err
+	}
+
+	return tlsConfigName, nil
+}
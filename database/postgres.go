@@ -0,0 +1,104 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"net/url"
+	"strconv"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	// Registers the pgx database/sql driver
+	_ "github.com/jackc/pgx/v5/stdlib"
+)
+
+// postgresErrUniqueViolation - PostgreSQL SQLSTATE for unique constraint violations
+const postgresErrUniqueViolation = "23505"
+
+// postgresSSLModes - libpq sslmode values of the TLS modes
+var postgresSSLModes = map[string]string{
+	"":            "disable",
+	TLSDisabled:   "disable",
+	TLSPreferred:  "prefer",
+	TLSSkipVerify: "require",
+	TLSVerify:     "verify-full",
+}
+
+// postgresDialect - PostgreSQL, with the pure Go pgx driver
+type postgresDialect struct{}
+
+// Name - the driver name used in the configuration
+func (postgresDialect) Name() string {
+	return DriverPostgres
+}
+
+// DriverName - name of the database/sql driver
+func (postgresDialect) DriverName() string {
+	return "pgx"
+}
+
+// DSN - builds the PostgreSQL connection URL. The read and write timeouts and the collation
+// are not supported; the charset sets the client encoding, and Params are passed as run-time parameters.
+func (postgresDialect) DSN(options *Options) (string, error) {
+	sslMode, ok := postgresSSLModes[options.TLS]
+	if !ok {
+		return "", fmt.Errorf("unknown TLS mode %q", options.TLS)
+	}
+
+	query := url.Values{}
+	for key, value := range options.Params {
+		query.Set(key, value)
+	}
+	query.Set("sslmode", sslMode)
+	if options.TLSCA != "" {
+		query.Set("sslrootcert", options.TLSCA)
+	}
+	if options.TLSCert != "" || options.TLSKey != "" {
+		query.Set("sslcert", options.TLSCert)
+		query.Set("sslkey", options.TLSKey)
+	}
+	if options.DialTimeout > 0 {
+		// Whole seconds only; round up, as zero would disable the timeout
+		query.Set("connect_timeout", strconv.Itoa(int(math.Ceil(options.DialTimeout.Seconds()))))
+	}
+	if options.Charset != "" {
+		query.Set("client_encoding", options.Charset)
+	}
+
+	dsn := &url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(options.Username, options.Password),
+		Host:     net.JoinHostPort(options.Hostname, strconv.Itoa(options.Port)),
+		Path:     "/" + options.Name,
+		RawQuery: query.Encode(),
+	}
+
+	return dsn.String(), nil
+}
+
+// Quote - quotes an identifier with double quotes
+func (postgresDialect) Quote(identifier string) string {
+	return `"` + identifier + `"`
+}
+
+// Now - current time in UTC, as the timestamp columns have no time zone
+func (postgresDialect) Now() string {
+	return "(NOW() AT TIME ZONE 'UTC')"
+}
+
+// CaseInsensitive - the lowercased expression, as comparisons are case sensitive;
+// the email unique index is on lower(email), so the email filter can use it
+func (postgresDialect) CaseInsensitive(expression string) string {
+	return "lower(" + expression + ")"
+}
+
+// DuplicateKey - returns the constraint of unique violation errors
+func (postgresDialect) DuplicateKey(err error) (string, bool) {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) || pgErr.Code != postgresErrUniqueViolation {
+		return "", false
+	}
+
+	return pgErr.ConstraintName, true
+}
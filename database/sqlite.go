@@ -0,0 +1,86 @@
+package database
+
+import (
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// sqliteUniqueMessage - start of the message of unique constraint violations, followed by <table>.<column>
+const sqliteUniqueMessage = "UNIQUE constraint failed: "
+
+// sqliteDialect - SQLite, with the pure Go modernc.org/sqlite driver
+type sqliteDialect struct{}
+
+// Name - the driver name used in the configuration
+func (sqliteDialect) Name() string {
+	return DriverSQLite
+}
+
+// DriverName - name of the database/sql driver
+func (sqliteDialect) DriverName() string {
+	return "sqlite"
+}
+
+// DSN - builds the SQLite DSN; the database name is the path of the database file.
+// The dial timeout is used as busy timeout, waiting for locks held by other connections.
+// Times are written in a format SQLite compares and parses back.
+func (sqliteDialect) DSN(options *Options) (string, error) {
+	query := url.Values{}
+	for key, value := range options.Params {
+		query.Set(key, value)
+	}
+	query.Add("_pragma", "foreign_keys(1)")
+	if options.DialTimeout > 0 {
+		query.Add("_pragma", "busy_timeout("+strconv.FormatInt(options.DialTimeout.Milliseconds(), 10)+")")
+	}
+	query.Set("_time_format", "sqlite")
+
+	return "file:" + options.Name + "?" + query.Encode(), nil
+}
+
+// Quote - quotes an identifier with double quotes
+func (sqliteDialect) Quote(identifier string) string {
+	return `"` + identifier + `"`
+}
+
+// Now - current time in UTC, in the format the driver writes times with
+func (sqliteDialect) Now() string {
+	return "strftime('%Y-%m-%d %H:%M:%S+00:00', 'now')"
+}
+
+// CaseInsensitive - the expression itself, as the email column has the NOCASE collation and LIKE ignores the case
+func (sqliteDialect) CaseInsensitive(expression string) string {
+	return expression
+}
+
+// DuplicateKey - returns the column of unique constraint violation errors; violations whose
+// message cannot be parsed are not reported, so they are not mistaken for a conflict on an unknown field
+func (sqliteDialect) DuplicateKey(err error) (string, bool) {
+	var sqliteErr *sqlite.Error
+	if !errors.As(err, &sqliteErr) || sqliteErr.Code() != sqlite3.SQLITE_CONSTRAINT_UNIQUE {
+		return "", false
+	}
+
+	// Message format: constraint failed: UNIQUE constraint failed: <table>.<column> (2067)
+	message := sqliteErr.Error()
+	start := strings.Index(message, sqliteUniqueMessage)
+	if start < 0 {
+		return "", false
+	}
+	key := message[start+len(sqliteUniqueMessage):]
+	if end := strings.Index(key, " "); end >= 0 {
+		key = key[:end]
+	}
+	// Expression indexes are reported as index '<name>', without a column
+	dot := strings.LastIndex(key, ".")
+	if dot < 0 || dot == len(key)-1 {
+		return "", false
+	}
+
+	return key[dot+1:], true
+}
@@ -1,27 +1,41 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"fmt"
 	"log"
+	"log/slog"
 	"net/http"
 	"os"
-	"strconv"
+	"os/signal"
+	"syscall"
+	"time"
 
-	"github.com/go-sql-driver/mysql"
 	"github.com/gorilla/mux"
+	"github.com/jmoiron/sqlx"
 
 	"sample-rest-api/app/api"
+	"sample-rest-api/app/logging"
+	"sample-rest-api/app/metrics"
+	"sample-rest-api/app/tracing"
 	"sample-rest-api/app/user"
 	"sample-rest-api/config"
 	"sample-rest-api/database"
 )
 
 // AddRoutes - add routes to router
-func AddRoutes(router *mux.Router, apiHandler *api.Handler) {
+func AddRoutes(router *mux.Router, apiHandler *api.Handler, userStore user.UserStore) {
 	v1Router := router.PathPrefix("/v1").Subrouter()
+	v1Router.Use(apiHandler.AuthMiddleware)
 
 	// Add Routes
-	user.AddRoutes(v1Router, apiHandler)
+	user.AddRoutes(v1Router, apiHandler, userStore)
+
+	// Health probes, outside of the versioned API
+	router.HandleFunc("/healthz", apiHandler.Liveness).Methods("GET")
+	router.HandleFunc("/readyz", apiHandler.Readiness).Methods("GET")
+	router.Handle("/metrics", metrics.Handler()).Methods("GET")
 
 	// Pretty print available routes to the CLI
 	router.Walk(func(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
@@ -34,44 +48,238 @@ func AddRoutes(router *mux.Router, apiHandler *api.Handler) {
 	})
 }
 
+// databaseOptions - prepares the database connection options
+func databaseOptions(cfg *config.Configuration) *database.Options {
+	dbConfig := cfg.Database
+	return &database.Options{
+		Driver:          dbConfig.Driver,
+		Hostname:        dbConfig.Hostname,
+		Port:            dbConfig.Port,
+		Username:        dbConfig.Username,
+		Password:        dbConfig.Password,
+		Name:            dbConfig.Name,
+		MaxOpenConns:    dbConfig.MaxOpenConns,
+		MaxIdleConns:    dbConfig.MaxIdleConns,
+		ConnMaxLifetime: dbConfig.ConnMaxLifetime,
+		ConnMaxIdleTime: dbConfig.ConnMaxIdleTime,
+		DialTimeout:     dbConfig.DialTimeout,
+		ReadTimeout:     dbConfig.ReadTimeout,
+		WriteTimeout:    dbConfig.WriteTimeout,
+		TLS:             dbConfig.TLS,
+		TLSCA:           dbConfig.TLSCA,
+		TLSCert:         dbConfig.TLSCert,
+		TLSKey:          dbConfig.TLSKey,
+		Charset:         dbConfig.Charset,
+		Collation:       dbConfig.Collation,
+		Params:          dbConfig.Params,
+		ConnectRetries:  dbConfig.ConnectRetries,
+		RetryBackoff:    dbConfig.RetryBackoff,
+		RetryMaxBackoff: dbConfig.RetryMaxBackoff,
+		ConnectTimeout:  dbConfig.ConnectTimeout,
+	}
+}
+
+// newLogger - creates the logger in the configured format, at the configured level
+func newLogger(cfg *config.Configuration, level *slog.LevelVar) (*slog.Logger, error) {
+	parsedLevel, err := logging.ParseLevel(cfg.Log.Level)
+	if err != nil {
+		return nil, err
+	}
+	level.Set(parsedLevel)
+
+	return logging.New(os.Stderr, cfg.Log.Format, level)
+}
+
+// newAuthenticator - creates the bearer token authenticator, loading the JWKS keys if configured
+func newAuthenticator(ctx context.Context, cfg *config.Configuration) (*api.Authenticator, error) {
+	authenticator := &api.Authenticator{
+		Issuer:   cfg.Auth.Issuer,
+		Audience: cfg.Auth.Audience,
+		Leeway:   cfg.Auth.Leeway,
+	}
+	if cfg.Auth.Secret != "" {
+		authenticator.Secret = []byte(cfg.Auth.Secret)
+	}
+	if cfg.Auth.JWKS != "" {
+		keySet, err := api.LoadKeySet(ctx, cfg.Auth.JWKS)
+		if err != nil {
+			return nil, err
+		}
+		authenticator.Keys = keySet
+	}
+
+	return authenticator, nil
+}
+
+// withMiddlewares - wraps the handler with the middlewares, the first one being the outermost
+func withMiddlewares(handler http.Handler, middlewares []mux.MiddlewareFunc) http.Handler {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		handler = middlewares[i](handler)
+	}
+
+	return handler
+}
+
+// pageLimits - page size limits of list requests, from the configuration
+func pageLimits(cfg *config.Configuration) api.PageLimits {
+	return api.PageLimits{
+		Default: cfg.Pagination.DefaultLimit,
+		Max:     cfg.Pagination.MaxLimit,
+	}
+}
+
 func main() {
+	os.Exit(run())
+}
+
+// run - runs the server, or the subcommand given as argument; returns the process exit code.
+// Returning rather than exiting lets the deferred shutdowns run.
+func run() int {
+	// Command line flags override configuration keys
+	config.RegisterFlags(flag.CommandLine)
+	flag.Parse()
+
 	// Load config
-	config.Load("config.yml")
-	if config.Config == nil {
-		os.Exit(2)
+	configStore, err := config.NewStore("config.yml")
+	if err != nil {
+		log.Println("Cannot load configuration:", err)
+		return 2
+	}
+	cfg := configStore.Get()
+
+	// Structured logging; the standard log package writes through the logger too
+	logLevel := new(slog.LevelVar)
+	logger, err := newLogger(cfg, logLevel)
+	if err != nil {
+		log.Println("Cannot create logger:", err)
+		return 2
 	}
-	log.Println("Loaded configuration")
+	slog.SetDefault(logger)
+	logger.Info("Loaded configuration")
 
-	// Prepare DSN for MySQL connection
-	dbConfig := config.Config.Database
-	mysqlConfig := mysql.Config{
-		User:                 dbConfig.Username,
-		Passwd:               dbConfig.Password,
-		Net:                  "tcp",
-		Addr:                 dbConfig.Hostname + ":" + strconv.Itoa(dbConfig.Port),
-		DBName:               dbConfig.Name,
-		ParseTime:            true,
-		AllowNativePasswords: true,
+	// Tracing; spans still buffered are flushed on exit
+	shutdownTracing, err := tracing.Setup(context.Background(), &tracing.Options{
+		Exporter:    cfg.Tracing.Exporter,
+		Endpoint:    cfg.Tracing.Endpoint,
+		File:        cfg.Tracing.File,
+		ServiceName: cfg.Tracing.ServiceName,
+		SampleRatio: cfg.Tracing.SampleRatio,
+	})
+	if err != nil {
+		logger.Error("Cannot set up tracing", "error", err)
+		return 2
 	}
+	defer func() {
+		flushCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(flushCtx); err != nil {
+			logger.Warn("Cannot flush spans", "error", err)
+		}
+	}()
+
+	// SIGINT and SIGTERM start the shutdown, or abort the connection retries; a second signal kills the process
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
 
-	// Connect to MySQL
-	dbHandle := database.Connect(mysqlConfig.FormatDSN())
-	if dbHandle == nil {
-		os.Exit(2)
+	// Schema migration commands
+	if flag.Arg(0) == "migrate" {
+		return runMigrate(ctx, cfg, flag.Args()[1:])
+	}
+
+	// User store; the memory backend runs without a database
+	var dbHandle *sqlx.DB
+	var userStore user.UserStore
+	if cfg.Store.Backend == "memory" {
+		logger.Warn("Using the in-memory user store, data is lost on exit")
+		userStore = user.NewMemoryStore()
+	} else {
+		// Connect to the database
+		dbHandle = database.Connect(ctx, databaseOptions(cfg))
+		if dbHandle == nil {
+			return 2
+		}
+		defer func() {
+			dbHandle.Close()
+			logger.Info("Database connection closed")
+		}()
+		logger.Info("Database connection established", "driver", cfg.Database.Driver)
+		if err := metrics.RegisterDB(dbHandle, cfg.Database.Name); err != nil {
+			logger.Warn("Cannot export connection pool metrics", "error", err)
+		}
+
+		// Apply pending migrations
+		if cfg.Database.AutoMigrate {
+			if err := migrateUp(dbHandle); err != nil {
+				logger.Error("Cannot apply migrations", "error", err)
+				return 2
+			}
+		}
+
+		userStore = user.NewSQLStore(dbHandle, api.QueryTimeouts{
+			Default:    cfg.Database.QueryTimeout,
+			Operations: cfg.Database.QueryTimeouts,
+		})
 	}
-	defer dbHandle.Close()
-	log.Println("MySQL connection established")
 
 	// Initialize API handler
 	apiHandler := api.Init(dbHandle)
+	apiHandler.Logger = logger
+	if cursorSecret := cfg.Pagination.CursorSecret; cursorSecret != "" {
+		apiHandler.CursorKey = []byte(cursorSecret)
+	}
+	apiHandler.AdminToken = cfg.Server.AdminToken
+	apiHandler.ReadinessTimeout = cfg.Server.ReadinessTimeout
+	if cfg.Auth.Enabled {
+		apiHandler.Auth, err = newAuthenticator(ctx, cfg)
+		if err != nil {
+			logger.Error("Cannot set up authentication", "error", err)
+			return 2
+		}
+	} else {
+		logger.Warn("Authentication is disabled, the API is public")
+	}
+	apiHandler.SetPageLimits(pageLimits(cfg))
+
+	// Apply configuration changes without a restart
+	configStore.Subscribe("pagination.", func(cfg *config.Configuration) {
+		apiHandler.SetPageLimits(pageLimits(cfg))
+	})
+	configStore.Subscribe("log.level", func(cfg *config.Configuration) {
+		level, _ := logging.ParseLevel(cfg.Log.Level)
+		logLevel.Set(level)
+	})
+	go configStore.Watch(ctx, 2*time.Second)
 
 	// Initialize router
 	router := mux.NewRouter().StrictSlash(true)
-	log.Println("Loading routes...")
-	AddRoutes(router, apiHandler)
+	// Middlewares run for matched routes; unmatched requests go through them via the error handlers
+	middlewares := []mux.MiddlewareFunc{
+		api.RequestIDMiddleware,
+		tracing.Middleware,
+		apiHandler.AccessLogMiddleware,
+		metrics.Middleware,
+	}
+	router.NotFoundHandler = withMiddlewares(api.NotFoundHandler(), middlewares)
+	router.MethodNotAllowedHandler = withMiddlewares(api.MethodNotAllowedHandler(), middlewares)
+	router.Use(middlewares...)
+	logger.Info("Loading routes...")
+	AddRoutes(router, apiHandler, userStore)
 
 	// Start the HTTP server
-	serverConfig := config.Config.Server
-	log.Println("Running HTTP server on port " + serverConfig.Port + "...")
-	log.Fatal(http.ListenAndServe(serverConfig.Hostname+":"+serverConfig.Port, router))
+	logger.Info("Running HTTP server", "address", cfg.Server.Hostname+":"+cfg.Server.Port)
+	err = runServer(ctx, newServer(cfg, router), cfg.Server.ShutdownDelay, cfg.Server.ShutdownTimeout, func() {
+		// Stop receiving new traffic
+		apiHandler.SetReady(false)
+	})
+	// Pending spans are exported, and the database pool is closed, by the deferred shutdowns
+	if err != nil {
+		logger.Error("HTTP server stopped with an error", "error", err)
+		return 1
+	}
+
+	return 0
 }
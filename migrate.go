@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+
+	"github.com/jmoiron/sqlx"
+
+	"sample-rest-api/config"
+	"sample-rest-api/database"
+	"sample-rest-api/database/migrate"
+)
+
+// migrateUsage - help for the migrate subcommands
+const migrateUsage = `Usage: sample-rest-api [flags] migrate <command>
+
+Commands:
+  up             apply all pending migrations
+  down           roll back the most recently applied migration
+  status         list migrations and whether they are applied
+  create <name>  create empty up/down scripts for every driver in ` + migrate.SourceDir
+
+// runMigrate - runs a migrate subcommand; returns the process exit code
+func runMigrate(ctx context.Context, cfg *config.Configuration, args []string) int {
+	if len(args) == 0 {
+		fmt.Println(migrateUsage)
+		return 2
+	}
+
+	// Creating migrations does not need a database connection; the schema is kept in sync across drivers
+	if args[0] == "create" {
+		if len(args) != 2 {
+			fmt.Println(migrateUsage)
+			return 2
+		}
+		for _, driver := range database.Drivers() {
+			paths, err := migrate.Create(filepath.Join(migrate.SourceDir, driver), args[1])
+			for _, path := range paths {
+				fmt.Println("Created", path)
+			}
+			if err != nil {
+				slog.Error("Cannot create migration", "name", args[1], "error", err)
+				return 1
+			}
+		}
+		return 0
+	}
+
+	dbHandle := database.Connect(ctx, databaseOptions(cfg))
+	if dbHandle == nil {
+		return 2
+	}
+	defer dbHandle.Close()
+
+	migrator, err := migrate.New(dbHandle)
+	if err != nil {
+		slog.Error("Cannot load migrations", "error", err)
+		return 1
+	}
+
+	switch args[0] {
+	case "up":
+		err = migrateUp(dbHandle)
+	case "down":
+		var migration *migrate.Migration
+		migration, err = migrator.Down()
+		if err == nil && migration == nil {
+			fmt.Println("No migration to roll back")
+		} else if err == nil {
+			fmt.Printf("Rolled back %04d_%s\n", migration.Version, migration.Name)
+		}
+	case "status":
+		var statuses []migrate.Status
+		statuses, err = migrator.Status()
+		for _, status := range statuses {
+			appliedAt := "pending"
+			if status.Applied {
+				appliedAt = status.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%04d_%-40s %s\n", status.Version, status.Name, appliedAt)
+		}
+	default:
+		fmt.Println(migrateUsage)
+		return 2
+	}
+
+	if err != nil {
+		slog.Error("Migration command failed", "command", args[0], "error", err)
+		return 1
+	}
+
+	return 0
+}
+
+// migrateUp - applies all pending migrations
+func migrateUp(dbHandle *sqlx.DB) error {
+	migrator, err := migrate.New(dbHandle)
+	if err != nil {
+		return err
+	}
+
+	applied, err := migrator.Up()
+	for _, migration := range applied {
+		slog.Info("Applied migration", "version", migration.Version, "name", migration.Name)
+	}
+	if err != nil {
+		return err
+	}
+
+	version, err := migrator.Version()
+	if err != nil {
+		return err
+	}
+	slog.Info("Database schema up to date", "version", version)
+
+	return nil
+}
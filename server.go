@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"sample-rest-api/config"
+)
+
+// newServer - creates the HTTP server with the configured address and timeouts
+func newServer(cfg *config.Configuration, handler http.Handler) *http.Server {
+	serverConfig := cfg.Server
+	return &http.Server{
+		Addr:              serverConfig.Hostname + ":" + serverConfig.Port,
+		Handler:           handler,
+		ReadTimeout:       serverConfig.ReadTimeout,
+		ReadHeaderTimeout: serverConfig.ReadHeaderTimeout,
+		WriteTimeout:      serverConfig.WriteTimeout,
+		IdleTimeout:       serverConfig.IdleTimeout,
+	}
+}
+
+// runServer - serves HTTP requests until the context is done. Then it calls onShutdown (e.g. to fail the
+// readiness probe), keeps serving for the shutdown delay so load balancers can react, stops accepting
+// connections and waits up to the shutdown timeout for in-flight requests to complete.
+func runServer(ctx context.Context, server *http.Server, shutdownDelay time.Duration, shutdownTimeout time.Duration,
+	onShutdown func()) error {
+	serverErr := make(chan error, 1)
+	go func() {
+		serverErr <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-serverErr:
+		return fmt.Errorf("HTTP server failed: %w", err)
+	case <-ctx.Done():
+	}
+
+	slog.Info("Shutting down HTTP server...")
+	if onShutdown != nil {
+		onShutdown()
+	}
+	if shutdownDelay > 0 {
+		select {
+		case err := <-serverErr:
+			return fmt.Errorf("HTTP server failed: %w", err)
+		case <-time.After(shutdownDelay):
+		}
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	err := server.Shutdown(shutdownCtx)
+	if err != nil {
+		// Requests still running after the deadline are cut off
+		server.Close()
+		return fmt.Errorf("HTTP server shutdown: %w", err)
+	}
+	slog.Info("HTTP server stopped")
+
+	return nil
+}
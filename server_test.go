@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// testServer - returns a server on a free local port, running the handler
+func testServer(t *testing.T, handler http.HandlerFunc) *http.Server {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal("Could not find a free port.")
+	}
+	addr := listener.Addr().String()
+	listener.Close()
+
+	return &http.Server{Addr: addr, Handler: handler}
+}
+
+// waitForServer - waits until the server accepts connections
+func waitForServer(t *testing.T, addr string) {
+	for i := 0; i < 100; i++ {
+		conn, err := net.Dial("tcp", addr)
+		if err == nil {
+			conn.Close()
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("Server not started.")
+}
+
+func TestRunServer(t *testing.T) {
+	t.Run("Shutdown - in-flight requests complete", func(t *testing.T) {
+		started := make(chan struct{})
+		server := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+			close(started)
+			time.Sleep(100 * time.Millisecond)
+			w.Write([]byte("done"))
+		})
+		ctx, cancel := context.WithCancel(context.Background())
+		serverErr := make(chan error, 1)
+		go func() {
+			serverErr <- runServer(ctx, server, 0, time.Second, nil)
+		}()
+		waitForServer(t, server.Addr)
+
+		// Shut down while the request is running
+		go func() {
+			<-started
+			cancel()
+		}()
+		response, err := http.Get("http://" + server.Addr)
+		if err != nil {
+			t.Fatal("In-flight request failed:", err)
+		}
+		body, _ := ioutil.ReadAll(response.Body)
+		response.Body.Close()
+		if string(body) != "done" {
+			t.Error("Incorrect response:", string(body))
+		}
+
+		if err := <-serverErr; err != nil {
+			t.Error("Graceful shutdown failed:", err)
+		}
+	})
+
+	t.Run("Shutdown - drain deadline", func(t *testing.T) {
+		started := make(chan struct{})
+		release := make(chan struct{})
+		defer close(release)
+		server := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+			close(started)
+			<-release
+		})
+		ctx, cancel := context.WithCancel(context.Background())
+		serverErr := make(chan error, 1)
+		go func() {
+			serverErr <- runServer(ctx, server, 0, 50*time.Millisecond, nil)
+		}()
+		waitForServer(t, server.Addr)
+
+		go http.Get("http://" + server.Addr)
+		<-started
+		cancel()
+
+		if err := <-serverErr; err == nil {
+			t.Error("Shutdown should fail when requests outlast the deadline")
+		}
+	})
+
+	t.Run("Shutdown - delay", func(t *testing.T) {
+		server := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("ok"))
+		})
+		ctx, cancel := context.WithCancel(context.Background())
+		notified := make(chan struct{})
+		serverErr := make(chan error, 1)
+		go func() {
+			serverErr <- runServer(ctx, server, 200*time.Millisecond, time.Second, func() {
+				close(notified)
+			})
+		}()
+		waitForServer(t, server.Addr)
+		cancel()
+		<-notified
+
+		// Requests are still served during the delay
+		response, err := http.Get("http://" + server.Addr)
+		if err != nil {
+			t.Fatal("Request during the shutdown delay failed:", err)
+		}
+		response.Body.Close()
+
+		if err := <-serverErr; err != nil {
+			t.Error("Graceful shutdown failed:", err)
+		}
+	})
+
+	t.Run("Listen failure", func(t *testing.T) {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal("Could not listen.")
+		}
+		defer listener.Close()
+
+		server := &http.Server{Addr: listener.Addr().String()}
+		if err := runServer(context.Background(), server, 0, time.Second, nil); err == nil {
+			t.Error("Server should fail on an address in use")
+		}
+	})
+}